@@ -0,0 +1,69 @@
+package golf
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ParseBootstrap parses only the options given by name, e.g. "config" and
+// "profile", from args. Other arguments are skipped whether registered or
+// not, required options are not checked and neither env nor config files are
+// read. The following Parse of the same args sets these options again, on
+// top of the config files loaded in between.
+func ParseBootstrap(args []string, names ...string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("golf parse panic: %v", reflect.TypeOf(r).Elem().Name())
+		}
+	}()
+	g.bootstrap = map[*golfOpt]bool{}
+	defer func() {
+		g.bootstrap = nil
+	}()
+	for _, name := range names {
+		opt := findOpt(name)
+		if opt == nil {
+			return fmt.Errorf("bootstrap option <%s> not registered", name)
+		}
+		g.bootstrap[opt] = true
+	}
+	if _, err := g.parseArgs(args); err != nil {
+		return err
+	}
+	for opt := range g.bootstrap {
+		opt.bootstrapped = opt.IsSet
+	}
+	g.assignLazies()
+	return nil
+}
+
+// keepBootValue remembers what value resolved to, e.g. the content read from
+// stdin for "-", so that Parse does not resolve it a second time.
+func (o *golfOpt) keepBootValue(value, resolved string) {
+	if o.bootValues == nil {
+		o.bootValues = map[string]string{}
+	}
+	o.bootValues[value] = resolved
+}
+
+func (g *golf) forgetBootValues() {
+	for _, opt := range g.all {
+		opt.bootValues = nil
+	}
+}
+
+// resetBootstrap lets Parse set the bootstrap options as if they were not
+// given before, so arrays and counts do not take their values twice.
+func (g *golf) resetBootstrap() {
+	for _, opt := range g.all {
+		if !opt.bootstrapped {
+			continue
+		}
+		opt.bootstrapped = false
+		opt.IsSet = false
+		opt.Source = Source{}
+		if opt.Type == optCount {
+			opt.ResultSetter.Target().SetInt(0)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package golf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseBootstrap(t *testing.T) {
+	dir := t.TempDir()
+	conf := writeFile(t, dir, "app.conf", "port = 8000\nhost = file.local\n[profile:prod]\nhost = prod.local\n")
+	args := []string{"--unknown", "-c", conf, "--port", "9000", "--profile", "prod", "-vv", "cmd", "--include", "a"}
+
+	Reset()
+	config := String("c", "config", "", "", "")
+	profile := ProfileFlag("profile", "")
+	includes := Array("", "include", "", "")
+	verbose := Count("v", "", "", "")
+	if err := ParseBootstrap(args, "config", "profile", "include", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if *config != conf || *profile != "prod" || !arrayEqual(*includes, []string{"a"}) || *verbose != 2 {
+		t.Fatalf("Got %s, %s, %q, %d", *config, *profile, *includes, *verbose)
+	}
+
+	if err := LoadConfig(*config); err != nil {
+		t.Fatal(err)
+	}
+	var opts struct {
+		Port int    `golf:"l:port"`
+		Host string `golf:"l:host"`
+		Cmd  string `golf:"pos:0"`
+	}
+	if err := ParseStruct(args[1:], &opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Port != 9000 || opts.Host != "prod.local" || opts.Cmd != "cmd" {
+		t.Fatalf("Got %+v", opts)
+	}
+	if !arrayEqual(*includes, []string{"a"}) || *verbose != 2 {
+		t.Fatalf("Expect bootstrap options once, got %q, %d", *includes, *verbose)
+	}
+
+	Reset()
+	if err := ParseBootstrap(args, "config"); err == nil || err.Error() != "bootstrap option <config> not registered" {
+		t.Fatalf("Expect unregistered error, got %v", err)
+	}
+}
+
+func TestParseBootstrapStdin(t *testing.T) {
+	Reset()
+	SetStdin(strings.NewReader("port = 8000\n"))
+	config := String("c", "config", "", "", "", FileValue(TrimNone, 0))
+	port := Int("p", "port", "", "", 0)
+	args := []string{"--config", "-", "--port", "9000"}
+	if err := ParseBootstrap(args, "config"); err != nil {
+		t.Fatal(err)
+	}
+	if *config != "port = 8000\n" || *port != 0 {
+		t.Fatalf("Got %q, %d", *config, *port)
+	}
+	if err := Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if *config != "port = 8000\n" || *port != 9000 {
+		t.Fatalf("Got %q, %d", *config, *port)
+	}
+	// values are only kept for the parse right after the bootstrap
+	if err := Parse(args); err == nil || !strings.Contains(err.Error(), "stdin already consumed") {
+		t.Fatalf("Expect stdin error, got %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package golf
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// configEntry is a "key = value" line of a config file, keys below a
+// "[section]" header are prefixed by "section.". Entries of a profile only
+// apply when it is active.
+type configEntry struct {
+	key     string
+	val     string
+	line    int
+	profile string
+}
+
+const profilePrefix = "profile:"
+
+type configFile struct {
+	path    string
+	entries []configEntry
+}
+
+// LoadConfig reads a config file of "key = value" lines which Parse applies
+// to the options with these keys. Values may be double quoted, lines
+// starting with '#' or ';' are comments and repeated keys add array items.
+// Files loaded later take precedence over earlier ones. Sections headed
+// "[profile:NAME]" or "[profile:NAME section]" overlay the base keys when
+// the profile is active, see SetProfile.
+func LoadConfig(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config %s: %v", path, err)
+	}
+	defer file.Close()
+
+	conf := configFile{path: path}
+	section, profile := "", ""
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";"):
+			continue
+		case strings.HasPrefix(text, "["):
+			if !strings.HasSuffix(text, "]") {
+				return fmt.Errorf("config %s:%d: unterminated section", path, line)
+			}
+			section, profile = strings.TrimSpace(text[1:len(text)-1]), ""
+			if strings.HasPrefix(section, profilePrefix) {
+				fields := strings.Fields(strings.TrimPrefix(section, profilePrefix))
+				if len(fields) < 1 || len(fields) > 2 {
+					return fmt.Errorf("config %s:%d: expect [profile:NAME section]", path, line)
+				}
+				section, profile = "", fields[0]
+				if len(fields) == 2 {
+					section = fields[1]
+				}
+			}
+			if section != "" {
+				section += "."
+			}
+			continue
+		}
+		idx := strings.Index(text, "=")
+		if idx == -1 {
+			return fmt.Errorf("config %s:%d: expect key = value", path, line)
+		}
+		key, val := strings.TrimSpace(text[:idx]), strings.TrimSpace(text[idx+1:])
+		if key == "" {
+			return fmt.Errorf("config %s:%d: empty key", path, line)
+		}
+		if strings.HasPrefix(val, `"`) {
+			if val, err = strconv.Unquote(val); err != nil {
+				return fmt.Errorf("config %s:%d: invalid quoted value", path, line)
+			}
+		}
+		conf.entries = append(conf.entries, configEntry{key: section + key, val: val, line: line, profile: profile})
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("config %s: %v", path, err)
+	}
+	g.configs = append(g.configs, conf)
+	return nil
+}
+
+func (g *golf) configOpt(key string) *golfOpt {
+	for _, opt := range g.all {
+		if !opt.Bare && !opt.reserved && opt.Key == key {
+			return opt
+		}
+	}
+	return nil
+}
+
+func (g *golf) applyConfigs() error {
+	profile, err := g.selectProfile()
+	if err != nil {
+		return err
+	}
+	for _, conf := range g.configs {
+		for _, entry := range conf.merged(profile) {
+			opt := g.configOpt(entry.key)
+			if opt == nil {
+				return fmt.Errorf("config %s:%d: unknown key <%s>", conf.path, entry.line, entry.key)
+			}
+			src := Source{Kind: SourceFile, Name: conf.path, Line: entry.line, Profile: entry.profile}
+			if err := opt.setFrom(src, entry.val); err != nil {
+				return fmt.Errorf("config %s:%d: %v", conf.path, entry.line, opt.maskError(err, entry.val))
+			}
+		}
+	}
+	return nil
+}
+
+// SampleConfig writes every visible option in the format read by
+// LoadConfig: its help and default as comments, followed by the key set to
+// its default. Options without default, required and secret ones are left
+// commented out.
+func SampleConfig() string {
+	builder := strings.Builder{}
+	sections := make([]string, 0)
+	grouped := map[string][]*golfOpt{}
+	for _, opt := range g.all {
+		if opt.Bare || opt.reserved || opt.Hidden || opt.Key == "" {
+			continue
+		}
+		section := ""
+		if idx := strings.LastIndex(opt.Key, "."); idx != -1 {
+			section = opt.Key[:idx]
+		}
+		if _, ok := grouped[section]; !ok {
+			sections = append(sections, section)
+		}
+		grouped[section] = append(grouped[section], opt)
+	}
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sections[i] == "" && sections[j] != ""
+	})
+	for i, section := range sections {
+		if i != 0 {
+			builder.WriteString("\n")
+		}
+		prefix := section + "."
+		if strings.HasPrefix(section, profilePrefix) {
+			// would be read as a profile, the keys are given in full instead
+			builder.WriteString("[]\n")
+			prefix = ""
+		} else if section != "" {
+			builder.WriteString("[" + section + "]\n")
+		}
+		for j, opt := range grouped[section] {
+			if j != 0 {
+				builder.WriteString("\n")
+			}
+			opt.writeSample(&builder, strings.TrimPrefix(opt.Key, prefix))
+		}
+	}
+	return builder.String()
+}
+
+func (o golfOpt) writeSample(builder *strings.Builder, key string) {
+	for _, line := range strings.Split(o.Help, "\n") {
+		if line != "" {
+			builder.WriteString("# " + line + "\n")
+		}
+	}
+	if o.Deprecated {
+		builder.WriteString("# " + o.deprecation() + "\n")
+	}
+	values := o.formatValues(o.Default)
+	if len(values) == 1 && values[0] == "" {
+		values = nil
+	}
+	if o.Required {
+		builder.WriteString("# (required)\n")
+	} else if len(values) != 0 {
+		builder.WriteString(fmt.Sprintf("# default: %s\n", o.display(quoteConfig(strings.Join(values, ", ")))))
+	}
+	if o.Required || o.Secret || len(values) == 0 {
+		builder.WriteString(fmt.Sprintf("# %s =\n", key))
+		return
+	}
+	if o.Type == optMap {
+		values = []string{strings.Join(values, ",")}
+	}
+	for _, value := range values {
+		builder.WriteString(fmt.Sprintf("%s = %s\n", key, quoteConfig(value)))
+	}
+}
+
+func quoteConfig(value string) string {
+	if value != strings.TrimSpace(value) || strings.HasPrefix(value, `"`) || strings.ContainsAny(value, "\r\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,165 @@
+package golf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	conf := writeFile(t, dir, "app.conf", `
+; server settings
+[server]
+listen_addr = "0.0.0.0:80 "
+[]
+verbose = true
+labels = env=prod,zone=a
+`)
+
+	Reset()
+	var opts struct {
+		Server struct {
+			ListenAddr string `golf:""`
+		} `golf:""`
+		Verbose bool              `golf:"l:verbose"`
+		Labels  map[string]string `golf:"l:labels"`
+	}
+	if err := LoadConfig(conf); err != nil {
+		t.Fatal(err)
+	}
+	if err := ParseStruct([]string{}, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Server.ListenAddr != "0.0.0.0:80 " || !opts.Verbose || opts.Labels["zone"] != "a" {
+		t.Fatalf("Got %+v", opts)
+	}
+}
+
+func TestLoadConfigError(t *testing.T) {
+	dir := t.TempDir()
+	for content, expect := range map[string]string{
+		"port 80\n":       "config %s:1: expect key = value",
+		"\n[server\n":     "config %s:2: unterminated section",
+		"name = \"bob\n":  "config %s:1: invalid quoted value",
+		"[profile:]\n":    "config %s:1: expect [profile:NAME section]",
+		" = 1\n":          "config %s:1: empty key",
+		"# c\nhost = x\n": "config %s:2: unknown key <host>",
+		"port = eighty\n": "config %s:1: arg<--port> require int, got <eighty>",
+	} {
+		path := writeFile(t, dir, "bad.conf", content)
+		Reset()
+		Int("", "port", "", "", 0)
+		err := LoadConfig(path)
+		if err == nil {
+			err = Parse([]string{})
+		}
+		if err == nil || err.Error() != fmt.Sprintf(expect, path) {
+			t.Fatalf("Expect %s, got %v", fmt.Sprintf(expect, path), err)
+		}
+	}
+}
+
+func TestSampleConfig(t *testing.T) {
+	type sampleConf struct {
+		Name   string   `golf:"l:name;required;help:'Service name'"`
+		Port   int      `golf:"l:port;default:8080;help:'Listen port'"`
+		Greet  string   `golf:"l:greet;default:' hi '"`
+		Tags   []string `golf:"l:tag;default:a,b;sep:,"`
+		Token  string   `golf:"l:token;secret;default:t0p"`
+		Old    string   `golf:"l:old;deprecated"`
+		Server struct {
+			Host string `golf:"l:host;help:'Upstream host'"`
+		} `golf:""`
+	}
+	Reset()
+	var conf sampleConf
+	// values given on the command line do not show up as defaults
+	args := []string{"--name", "x", "--port", "9999", "--greet", "yo", "--tag", "z", "--token", "t1"}
+	if err := ParseStruct(args, &conf); err != nil {
+		t.Fatal(err)
+	}
+	sample := SampleConfig()
+	expect := `# Service name
+# (required)
+# name =
+
+# Listen port
+# default: 8080
+port = 8080
+
+# default: " hi "
+greet = " hi "
+
+# default: a, b
+tag = a
+tag = b
+
+# default: ******
+# token =
+
+# deprecated
+# old =
+
+[server]
+# Upstream host
+# host =
+`
+	if sample != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, sample)
+	}
+
+	path := writeFile(t, t.TempDir(), "sample.conf", sample)
+	Reset()
+	var loaded sampleConf
+	if err := LoadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := ParseStruct([]string{"--name", "x"}, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Port != 8080 || loaded.Greet != " hi " || !arrayEqual(loaded.Tags, []string{"a", "b"}) || loaded.Token != "t0p" {
+		t.Fatalf("Got %+v", loaded)
+	}
+}
+
+func TestSampleConfigProfileSection(t *testing.T) {
+	type profileConf struct {
+		Profile struct {
+			Name string `golf:"l:name;default:alice"`
+		} `golf:""`
+		Odd string `golf:"l:profile:x.key;default:odd"`
+	}
+	Reset()
+	var conf profileConf
+	if err := ParseStruct([]string{}, &conf); err != nil {
+		t.Fatal(err)
+	}
+	sample := SampleConfig()
+	expect := `[profile]
+# default: alice
+name = alice
+
+[]
+# default: odd
+profile:x.key = odd
+`
+	if sample != expect {
+		t.Fatalf("Expect:\n%s\nGot:\n%s", expect, sample)
+	}
+
+	path := writeFile(t, t.TempDir(), "sample.conf", sample)
+	Reset()
+	var loaded profileConf
+	if err := LoadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := ParseStruct([]string{}, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if src, _ := SourceOf("profile.name"); loaded.Profile.Name != "alice" || src.Kind != SourceFile {
+		t.Fatalf("Got %+v from %s", loaded, src)
+	}
+	if src, _ := SourceOf("profile:x.key"); loaded.Odd != "odd" || src.Kind != SourceFile {
+		t.Fatalf("Got %+v from %s", loaded, src)
+	}
+}
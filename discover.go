@@ -0,0 +1,84 @@
+package golf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultConfigPaths returns the places DiscoverConfig searches for the
+// config files of prog, lowest priority first: /etc/<prog>/config.*,
+// $XDG_CONFIG_HOME/<prog>/config.* (~/.config without it), ~/.<prog>rc and
+// ./.<prog>.* in the working directory.
+func DefaultConfigPaths(prog string) []string {
+	paths := []string{filepath.Join("/etc", prog, "config.*")}
+	home, _ := os.UserHomeDir()
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" && home != "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	if xdg != "" {
+		paths = append(paths, filepath.Join(xdg, prog, "config.*"))
+	}
+	if home != "" {
+		paths = append(paths, filepath.Join(home, "."+prog+"rc"))
+	}
+	return append(paths, "."+prog+".*")
+}
+
+// SetConfigPaths replaces the search list of DiscoverConfig, lowest priority
+// first. Paths may start with "~/", contain $VARS and glob patterns.
+func SetConfigPaths(paths ...string) {
+	g.configPaths = paths
+}
+
+// configExts are the extensions of the files LoadConfig reads, glob patterns
+// skip every other file like backups, swap files or JSON documents.
+var configExts = []string{".ini", ".conf"}
+
+// DiscoverConfig loads every existing file of the search list, see
+// DefaultConfigPaths and SetConfigPaths, so that files found later take
+// precedence. Files matched by a glob pattern need a ".ini" or ".conf"
+// extension, paths without pattern are loaded as they are. It returns the
+// files loaded.
+func DiscoverConfig(prog string) ([]string, error) {
+	paths := g.configPaths
+	if paths == nil {
+		paths = DefaultConfigPaths(prog)
+	}
+	used := make([]string, 0)
+	for _, pattern := range paths {
+		path := expandPath(pattern)
+		files, err := filepath.Glob(path)
+		if err != nil {
+			return used, fmt.Errorf("config search %s: %v", pattern, err)
+		}
+		sort.Strings(files)
+		glob := strings.ContainsAny(path, "*?[")
+		for _, file := range files {
+			if info, err := os.Stat(file); err != nil || info.IsDir() {
+				continue
+			}
+			if glob && !existInArray(configExts, filepath.Ext(file)) {
+				continue
+			}
+			if err := LoadConfig(file); err != nil {
+				return used, err
+			}
+			used = append(used, file)
+		}
+	}
+	return used, nil
+}
+
+func expandPath(path string) string {
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = home + path[1:]
+		}
+	}
+	return path
+}
@@ -0,0 +1,64 @@
+package golf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultConfigPaths(t *testing.T) {
+	home, xdg := os.Getenv("HOME"), os.Getenv("XDG_CONFIG_HOME")
+	defer os.Setenv("HOME", home)
+	defer os.Setenv("XDG_CONFIG_HOME", xdg)
+
+	os.Setenv("HOME", "/home/me")
+	os.Setenv("XDG_CONFIG_HOME", "")
+	expect := []string{"/etc/app/config.*", "/home/me/.config/app/config.*", "/home/me/.apprc", ".app.*"}
+	if paths := DefaultConfigPaths("app"); !arrayEqual(paths, expect) {
+		t.Fatalf("Expect %q, got %q", expect, paths)
+	}
+	os.Setenv("XDG_CONFIG_HOME", "/xdg")
+	if paths := DefaultConfigPaths("app"); paths[1] != "/xdg/app/config.*" {
+		t.Fatalf("Got %q", paths)
+	}
+}
+
+func TestDiscoverConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "xdg", "app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	system := writeFile(t, dir, "system.conf", "port = 1\nhost = system\nuser = root\n")
+	xdg := writeFile(t, filepath.Join(dir, "xdg", "app"), "config.ini", "port = 2\nhost = xdg\n")
+	rc := writeFile(t, dir, ".apprc", "port = 3\n")
+	local := writeFile(t, dir, ".app.conf", "user = local\n")
+	for _, skipped := range []string{"config.ini.bak", "config.ini~", "config.json", ".config.ini.swp"} {
+		writeFile(t, filepath.Join(dir, "xdg", "app"), skipped, "{not ini")
+	}
+	writeFile(t, dir, ".app.json", "{}")
+	writeFile(t, dir, ".app.conf.bak", "broken")
+	os.Setenv("GOLF_TEST_DIR", dir)
+	defer os.Unsetenv("GOLF_TEST_DIR")
+
+	Reset()
+	SetConfigPaths("$GOLF_TEST_DIR/system.conf", "$GOLF_TEST_DIR/xdg/app/config.*", "$GOLF_TEST_DIR/missing", "$GOLF_TEST_DIR/.apprc", "$GOLF_TEST_DIR/.app.*")
+	port := Int("", "port", "", "", 0)
+	host := String("", "host", "", "", "")
+	user := String("", "user", "", "", "")
+	used, err := DiscoverConfig("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !arrayEqual(used, []string{system, xdg, rc, local}) {
+		t.Fatalf("Got %q", used)
+	}
+	if err := Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if *port != 3 || *host != "xdg" || *user != "local" {
+		t.Fatalf("Got %d, %s, %s", *port, *host, *user)
+	}
+	if src, _ := SourceOf("port"); src.Name != rc || len(findOpt("port").Overridden) != 2 {
+		t.Fatalf("Got %s, %v", src, findOpt("port").Overridden)
+	}
+}
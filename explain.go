@@ -0,0 +1,98 @@
+package golf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const defaultExplainFlag = "golf-explain"
+
+// SetExplainFlag renames the long flag with which ParseOSArgs prints Explain
+// and exits, an empty name disables it.
+func SetExplainFlag(name string) {
+	g.explainFlag = name
+}
+
+// SetExplainOutput redirects the report printed for the explain flag.
+func SetExplainOutput(w io.Writer) {
+	g.explainOut = w
+}
+
+// Explain lists every option with its effective value, the source it came
+// from, the sources it overrode and its default, after the config files
+// loaded and the active profile if any. Secret values are masked.
+func Explain() string {
+	builder := strings.Builder{}
+	if len(g.configs) != 0 {
+		paths := make([]string, len(g.configs))
+		for i, conf := range g.configs {
+			paths[i] = conf.path
+		}
+		builder.WriteString(fmt.Sprintf("config: %s\n", strings.Join(paths, ", ")))
+	}
+	if g.activeProfile != "" {
+		builder.WriteString(fmt.Sprintf("profile: %s (%s)\n", g.activeProfile, g.profileFrom))
+	}
+	w := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "OPTION\tVALUE\tSOURCE\tOVERRIDDEN\tDEFAULT")
+	for _, opt := range g.all {
+		if opt.reserved {
+			continue
+		}
+		overridden := make([]string, len(opt.Overridden))
+		for i, src := range opt.Overridden {
+			overridden[i] = src.String()
+		}
+		if len(overridden) == 0 {
+			overridden = append(overridden, "-")
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			opt.explainName(),
+			opt.display(fmt.Sprint(opt.currentValue())),
+			opt.Source,
+			strings.Join(overridden, ", "),
+			opt.display(fmt.Sprint(opt.Default)))
+	}
+	_ = w.Flush()
+	return builder.String()
+}
+
+func (o golfOpt) explainName() string {
+	if o.Long != "" {
+		return "--" + o.Long
+	}
+	if o.Short != "" {
+		return "-" + o.Short
+	}
+	return o.Name
+}
+
+// addExplainFlag registers the explain flag unless its name is taken, the
+// one added by an earlier call is reused.
+func (g *golf) addExplainFlag() *bool {
+	if g.explainFlag == "" {
+		return new(bool)
+	}
+	if explain := g.reservedBool("", g.explainFlag); explain != nil {
+		return explain
+	}
+	if _, ok := g.longs[g.explainFlag]; ok {
+		return new(bool)
+	}
+	explain := Bool("", g.explainFlag, "", "Explain where option values come from", false, Hidden())
+	g.all[len(g.all)-1].reserved = true
+	return explain
+}
+
+// runExplain prints the report and exits, a failed Parse exits with 1.
+func (g *golf) runExplain(err error) {
+	_, _ = fmt.Fprint(g.explainOut, Explain())
+	if err != nil {
+		_, _ = fmt.Fprintf(g.explainOut, "error: %v\n", err)
+		g.exit(1)
+		return
+	}
+	g.exit(0)
+}
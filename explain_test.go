@@ -0,0 +1,59 @@
+package golf
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	dir := t.TempDir()
+	conf := writeFile(t, dir, "app.conf", "port = 8000\ntoken = s3cret\n")
+
+	Reset()
+	out := &bytes.Buffer{}
+	code := -1
+	SetExplainOutput(out)
+	g.exit = func(c int) { code = c }
+	Int("p", "port", "", "Port", 80)
+	String("", "token", "", "Token", "", Secret())
+	String("", "host", "", "Host", "localhost")
+	if err := LoadConfig(conf); err != nil {
+		t.Fatal(err)
+	}
+	os.Args = []string{"./test_exec", "--port", "9000", "--golf-explain"}
+	if _, err := ParseOSArgs(); err != nil {
+		t.Fatal(err)
+	}
+	expect := [][]string{
+		{"config:", conf},
+		{"OPTION", "VALUE", "SOURCE", "OVERRIDDEN", "DEFAULT"},
+		{"--port", "9000", "arg", "#0", "file", conf + ":1", "80"},
+		{"--token", "******", "file", conf + ":2", "-"},
+		{"--host", "localhost", "default", "-", "localhost"},
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if code != 0 || len(lines) != len(expect) {
+		t.Fatalf("Got exit %d:\n%s", code, out.String())
+	}
+	for i, line := range lines {
+		if !arrayEqual(strings.Fields(line), expect[i]) {
+			t.Fatalf("Expect %q, got %q", expect[i], line)
+		}
+	}
+	if strings.Contains(out.String(), "--help") || strings.Contains(out.String(), "--golf-explain") {
+		t.Fatalf("Expect flags added by golf to be left out:\n%s", out.String())
+	}
+
+	Reset()
+	out.Reset()
+	SetExplainOutput(out)
+	SetExplainFlag("explain")
+	g.exit = func(c int) { code = c }
+	MustString("", "name", "", "Name")
+	os.Args = []string{"./test_exec", "--explain"}
+	if _, err := ParseOSArgs(); err == nil || code != 1 || !strings.HasSuffix(out.String(), "error: missing argument: --name string\n") {
+		t.Fatalf("Got exit %d, %v:\n%s", code, err, out.String())
+	}
+}
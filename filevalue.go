@@ -0,0 +1,109 @@
+package golf
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+type TrimMode int
+
+const (
+	// TrimNewline drops a single trailing "\n" or "\r\n".
+	TrimNewline TrimMode = iota
+	// TrimSpace drops all leading and trailing white space.
+	TrimSpace
+	// TrimNone keeps the content as it is.
+	TrimNone
+)
+
+// DefaultFileValueLimit caps values read by FileValue options with limit 0.
+const DefaultFileValueLimit = 1 << 20
+
+// FileValue lets an option take "@path" to read its value from a file and
+// "-" to read it from stdin. "@@x" stands for the literal "@x" and "@-" for
+// a literal "-", a file named "-" is given as "@./-". A limit of 0 means
+// DefaultFileValueLimit, a negative one no limit at all.
+func FileValue(trim TrimMode, limit int64) OptSetting {
+	return func(o *golfOpt) {
+		o.FromFile = true
+		o.FileTrim = trim
+		o.FileLimit = limit
+	}
+}
+
+func SetStdin(r io.Reader) {
+	g.stdin = r
+	g.stdinUsed = false
+}
+
+func parseTrimMode(s string) (TrimMode, error) {
+	switch strings.ToLower(s) {
+	case "", "newline":
+		return TrimNewline, nil
+	case "space":
+		return TrimSpace, nil
+	case "none":
+		return TrimNone, nil
+	default:
+		return TrimNewline, fmt.Errorf("unknown trim mode %s", s)
+	}
+}
+
+// resolveValue replaces "@path" and "-" by the content they point to.
+func (o *golfOpt) resolveValue(value string) (string, error) {
+	if !o.FromFile {
+		return value, nil
+	}
+	var reader io.Reader
+	source := ""
+	switch {
+	case strings.HasPrefix(value, "@@"):
+		return value[1:], nil
+	case value == "@-":
+		return "-", nil
+	case strings.HasPrefix(value, "@") && len(value) > 1:
+		source = value[1:]
+		file, err := os.Open(source)
+		if err != nil {
+			return "", fmt.Errorf("arg<%s> read value: %v", o.debugArg(), err)
+		}
+		defer file.Close()
+		reader = file
+	case value == "-":
+		if g.stdinUsed {
+			return "", fmt.Errorf("arg<%s> read value: stdin already consumed", o.debugArg())
+		}
+		g.stdinUsed = true
+		source = "stdin"
+		reader = g.stdin
+	default:
+		return value, nil
+	}
+
+	limit := o.FileLimit
+	if limit == 0 {
+		limit = DefaultFileValueLimit
+	}
+	if limit > 0 {
+		reader = io.LimitReader(reader, limit+1)
+	}
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("arg<%s> read value from %s: %v", o.debugArg(), source, err)
+	}
+	if limit > 0 && int64(len(content)) > limit {
+		return "", fmt.Errorf("arg<%s> value from %s exceeds %d bytes", o.debugArg(), source, limit)
+	}
+	str := string(content)
+	switch o.FileTrim {
+	case TrimNewline:
+		str = strings.TrimSuffix(str, "\n")
+		str = strings.TrimSuffix(str, "\r")
+	case TrimSpace:
+		str = strings.TrimSpace(str)
+	}
+	return str, nil
+}
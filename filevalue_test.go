@@ -0,0 +1,62 @@
+package golf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFileValue(t *testing.T) {
+	dir := t.TempDir()
+	secret := writeFile(t, dir, "token", "s3cret\r\n")
+	padded := writeFile(t, dir, "padded", "  text \n\n")
+
+	Reset()
+	SetStdin(strings.NewReader("from stdin\n"))
+	token := String("t", "token", "", "", "", FileValue(TrimNewline, 0))
+	body := String("b", "body", "", "", "", FileValue(TrimNone, -1))
+	plain := String("p", "plain", "", "", "")
+	if err := Parse([]string{"--token=@" + secret, "--body", "-", "-p", "@" + secret}); err != nil {
+		t.Fatal(err)
+	}
+	if *token != "s3cret" || *body != "from stdin\n" || *plain != "@"+secret {
+		t.Fatalf("Got %q, %q, %q", *token, *body, *plain)
+	}
+
+	Reset()
+	var opts struct {
+		Text  string `golf:"l:text;file:space"`
+		Label string `golf:"l:label;file"`
+		Dash  string `golf:"l:dash;file"`
+	}
+	if err := ParseStruct([]string{"--text", "@" + padded, "--label", "@@home", "--dash", "@-"}, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Text != "text" || opts.Label != "@home" || opts.Dash != "-" {
+		t.Fatalf("Got %q, %q, %q", opts.Text, opts.Label, opts.Dash)
+	}
+}
+
+func TestFileValueError(t *testing.T) {
+	dir := t.TempDir()
+	big := writeFile(t, dir, "big", "0123456789")
+
+	Reset()
+	String("t", "token", "", "", "", FileValue(TrimNewline, 4))
+	if err := Parse([]string{"--token=@" + big}); err == nil || !strings.Contains(err.Error(), "exceeds 4 bytes") {
+		t.Fatalf("Expect size error, got %v", err)
+	}
+
+	Reset()
+	String("t", "token", "", "", "", FileValue(TrimNewline, 0))
+	if err := Parse([]string{"--token=@" + dir + "/missing"}); err == nil || !strings.HasPrefix(err.Error(), "arg<-t/--token> read value") {
+		t.Fatalf("Expect missing file error, got %v", err)
+	}
+
+	Reset()
+	SetStdin(strings.NewReader("once"))
+	String("a", "", "", "", "", FileValue(TrimNewline, 0))
+	String("b", "", "", "", "", FileValue(TrimNewline, 0))
+	if err := Parse([]string{"-a", "-", "-b", "-"}); err == nil || !strings.Contains(err.Error(), "stdin already consumed") {
+		t.Fatalf("Expect stdin error, got %v", err)
+	}
+}
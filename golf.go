@@ -1,12 +1,19 @@
 package golf
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type any interface{}
@@ -15,14 +22,49 @@ type golf struct {
 	shorts map[string]*golfOpt
 	longs  map[string]*golfOpt
 	all    []*golfOpt
+	warn   io.Writer
+
+	usageAliases  bool
+	lazies        []lazyStruct
+	naming        Naming
+	autoShort     bool
+	fallbackTags  []string
+	strict        bool
+	problems      []string
+	respFormat    ResponseFormat
+	respDepth     int
+	stdin         io.Reader
+	stdinUsed     bool
+	precedence    []SourceKind
+	useEnv        bool
+	envPrefix     string
+	configs       []configFile
+	explainFlag   string
+	explainOut    io.Writer
+	exit          func(code int)
+	profile       string
+	profileOpt    *golfOpt
+	profileEnv    string
+	activeProfile string
+	profileFrom   string
+	bootstrap     map[*golfOpt]bool
+	configPaths   []string
+	walking       map[reflect.Type]bool
 }
 
-var (
-	tagPartReg = regexp.MustCompile("(([^;:]+):\\s*'([^']*)'\\s*;?)|(([^:;'\"]+);)|(([^;:]+):([^;]+);?)|([^:;'\"]+)")
-	tagFullReg = regexp.MustCompile("((([^;:]+):\\s*'([^']*)'\\s*;?)|(([^:;'\"]+);)|(([^;:]+):([^;]+);?)|([^:;'\"]+)\\s*)+")
+// lazyStruct is a nil pointer-to-struct field, its options are bound to tmp
+// which is only assigned to the field once one of them has been set.
+type lazyStruct struct {
+	field reflect.Value
+	tmp   reflect.Value
+	opts  []*golfOpt
+}
 
+var (
 	nameFirstCap = regexp.MustCompile("(.)([A-Z][a-z]+)")
 	nameAllCap   = regexp.MustCompile("([a-z0-9])([A-Z])")
+
+	validName = regexp.MustCompile(`^[^-=\s][^=\s]*$`)
 )
 
 var g golf
@@ -39,12 +81,55 @@ const (
 	optFloat
 	optString
 	optArray
-	optBareArray
-	optBareString
+	optCount
+	optMap
+	optDuration
+	optValue
+	optUint
+)
+
+// Value is implemented by custom option types, for struct fields, Var and
+// the elements of array options alike.
+type Value interface {
+	String() string
+	Set(string) error
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	valueType    = reflect.TypeOf((*Value)(nil)).Elem()
+)
+
+type MapPolicy int
+
+const (
+	MapOverwrite MapPolicy = iota
+	MapKeepFirst
+	MapReject
 )
 
 type resultSetter struct {
 	resultPtr *reflect.Value
+	// optional results are pointer fields, allocated when first set
+	optional bool
+}
+
+func (p resultSetter) Type() reflect.Type {
+	if p.optional {
+		return p.resultPtr.Type().Elem()
+	}
+	return p.resultPtr.Type()
+}
+
+func (p resultSetter) Target() *reflect.Value {
+	if !p.optional {
+		return p.resultPtr
+	}
+	if p.resultPtr.IsNil() {
+		p.resultPtr.Set(reflect.New(p.resultPtr.Type().Elem()))
+	}
+	elem := p.resultPtr.Elem()
+	return &elem
 }
 
 func (p resultSetter) SetValue(v any) (ok bool) {
@@ -53,21 +138,35 @@ func (p resultSetter) SetValue(v any) (ok bool) {
 			ok = false
 		}
 	}()
-	p.resultPtr.Set(reflect.ValueOf(v).Convert(p.resultPtr.Type()))
+	target := p.Target()
+	target.Set(reflect.ValueOf(v).Convert(target.Type()))
 	return true
 }
 
-func (p resultSetter) AddValue(v any) (ok bool) {
-	defer func() {
-		if r := recover(); r != nil {
-			ok = false
-		}
-	}()
-
-	p.resultPtr.Set(reflect.Append(*p.resultPtr, reflect.ValueOf(v)))
+func (p resultSetter) AddValue(v reflect.Value) bool {
+	target := p.Target()
+	if target.Kind() != reflect.Slice || !v.Type().AssignableTo(target.Type().Elem()) {
+		return false
+	}
+	target.Set(reflect.Append(*target, v))
 	return true
 }
 
+// Value returns the custom Value behind the result, if it implements one.
+func (p resultSetter) Value() (Value, bool) {
+	target := p.Target()
+	if target.CanAddr() {
+		if v, ok := target.Addr().Interface().(Value); ok {
+			return v, true
+		}
+	}
+	if target.CanInterface() {
+		v, ok := target.Interface().(Value)
+		return v, ok
+	}
+	return nil, false
+}
+
 type golfOpt struct {
 	Short        string
 	Long         string
@@ -77,10 +176,233 @@ type golfOpt struct {
 	Type         optType
 	ResultSetter resultSetter
 	IsSet        bool
+	Source       Source
+	Overridden   []Source
 	Help         string
+	Hidden       bool
+	Secret       bool
+	Deprecated   bool
+	DeprecateMsg string
+	Replacement  string
+	ShortAliases []string
+	LongAliases  []string
+	MapPolicy    MapPolicy
+	Sep          string
+	MinItems     int
+	MaxItems     int
+	Bare         bool
+	Position     int
+	Env          string
+	Key          string
+	Origin       string
+	Site         string
+	FromFile     bool
+	FileTrim     TrimMode
+	FileLimit    int64
+	defaultStr   string
+	hasDefault   bool
+	// fixedPos marks positionals placed by a constructor or a pos tag,
+	// no two of them may share a position
+	fixedPos bool
+	warned   bool
+	// reserved marks the flags golf adds itself, like help and explain,
+	// which are left out of reports and dumps
+	reserved     bool
+	bootstrapped bool
+	bootValues   map[string]string
+}
+
+// OptSetting adjusts an option while it is being registered by one of the
+// constructors, e.g. String("", "old", "", "", "", Hidden()).
+type OptSetting func(o *golfOpt)
+
+func Hidden() OptSetting {
+	return func(o *golfOpt) {
+		o.Hidden = true
+	}
+}
+
+// Secret masks the value of an option in Usage, errors and dumps.
+func Secret() OptSetting {
+	return func(o *golfOpt) {
+		o.Secret = true
+	}
+}
+
+func Deprecated(replacement, msg string) OptSetting {
+	return func(o *golfOpt) {
+		o.Deprecated = true
+		o.Replacement = replacement
+		o.DeprecateMsg = msg
+	}
+}
+
+// Alias registers extra names for an option. Names are given as "-x"/"--xx";
+// without dashes a single character is taken as short, anything else as long.
+func Alias(names ...string) OptSetting {
+	return func(o *golfOpt) {
+		o.addAliases(names)
+	}
+}
+
+func (o *golfOpt) addAliases(names []string) {
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+			continue
+		case strings.HasPrefix(name, "--"):
+			o.LongAliases = append(o.LongAliases, strings.TrimPrefix(name, "--"))
+		case strings.HasPrefix(name, "-"):
+			o.ShortAliases = append(o.ShortAliases, strings.TrimPrefix(name, "-"))
+		case len(name) == 1:
+			o.ShortAliases = append(o.ShortAliases, name)
+		default:
+			o.LongAliases = append(o.LongAliases, name)
+		}
+	}
+}
+
+func DuplicateKeys(policy MapPolicy) OptSetting {
+	return func(o *golfOpt) {
+		o.MapPolicy = policy
+	}
+}
+
+// Separator splits every occurrence of an array option, e.g. "--ports 80,443".
+func Separator(sep string) OptSetting {
+	return func(o *golfOpt) {
+		o.Sep = sep
+	}
+}
+
+// Items bounds the number of elements of an array option, max <= 0 means
+// unbounded.
+func Items(min, max int) OptSetting {
+	return func(o *golfOpt) {
+		o.MinItems = min
+		o.MaxItems = max
+	}
+}
+
+// Optional makes a positional optional, defaultVal is converted like a value
+// given on the command line.
+func Optional(defaultVal string) OptSetting {
+	return func(o *golfOpt) {
+		o.Required = false
+		o.MinItems = 0
+		o.defaultStr = defaultVal
+		o.hasDefault = defaultVal != ""
+	}
+}
+
+// register makes the option reachable by all its names, refusing names
+// which are malformed or already taken by another option.
+func (g *golf) register(opt *golfOpt) error {
+	problems := make([]string, 0)
+	check := func(names map[string]*golfOpt, dash string, list []string) {
+		for _, name := range list {
+			if !validName.MatchString(name) {
+				problems = append(problems, fmt.Sprintf("invalid name <%s%s> of %s", dash, name, opt.describe()))
+			} else if prev, ok := names[name]; ok && prev != opt {
+				problems = append(problems, fmt.Sprintf("duplicate name %s%s: %s and %s", dash, name, prev.describe(), opt.describe()))
+			}
+		}
+	}
+	shorts := opt.ShortAliases
+	if opt.Short != "" {
+		shorts = append([]string{opt.Short}, shorts...)
+	}
+	longs := opt.LongAliases
+	if opt.Long != "" {
+		longs = append([]string{opt.Long}, longs...)
+	}
+	check(g.shorts, "-", shorts)
+	check(g.longs, "--", longs)
+	if opt.Bare && opt.fixedPos {
+		for _, prev := range g.all {
+			if prev.Bare && prev.fixedPos && prev.Position == opt.Position {
+				problems = append(problems, fmt.Sprintf("duplicate position %s: %s and %s", opt.positionName(), prev.describe(), opt.describe()))
+			}
+		}
+	}
+	if len(problems) != 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	for _, short := range shorts {
+		g.shorts[short] = opt
+	}
+	for _, long := range longs {
+		g.longs[long] = opt
+	}
+	g.all = append(g.all, opt)
+	return nil
+}
+
+// mustRegister is register for the constructors, which have no error to
+// return: the problem is recorded in strict mode and panics otherwise.
+func (g *golf) mustRegister(opt *golfOpt) {
+	if err := g.register(opt); err != nil {
+		if err := g.fail(err); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func callSite() string {
+	// skip callSite, addOpt/addBare and the constructor itself
+	if _, file, line, ok := runtime.Caller(3); ok {
+		return fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+	return ""
+}
+
+func (g *golf) positionals() []*golfOpt {
+	bares := make([]*golfOpt, 0)
+	for _, opt := range g.all {
+		if opt.Bare {
+			bares = append(bares, opt)
+		}
+	}
+	sort.SliceStable(bares, func(i, j int) bool {
+		return bares[i].Position < bares[j].Position
+	})
+	return bares
 }
 
-func (g *golf) addOpt(rs resultSetter, short, long, name, help string, defaultVal any, required bool, kind optType) {
+func (o golfOpt) positionName() string {
+	if o.Position == math.MaxInt32 {
+		return "rest"
+	}
+	return strconv.Itoa(o.Position)
+}
+
+func (g *golf) addBare(rs resultSetter, name, help string, defaultVal any, kind optType, settings []OptSetting) {
+	opt := golfOpt{
+		Name:         name,
+		Required:     kind != optArray,
+		Default:      defaultVal,
+		Type:         kind,
+		ResultSetter: rs,
+		Help:         help,
+		Bare:         true,
+		Position:     len(g.positionals()),
+		fixedPos:     true,
+		Site:         callSite(),
+	}
+	for _, set := range settings {
+		set(&opt)
+	}
+	if err := opt.applyDefault(); err != nil {
+		if err := g.fail(fmt.Errorf("golf positional <%s>: %v", name, err)); err != nil {
+			panic(err)
+		}
+	}
+	g.mustRegister(&opt)
+}
+
+func (g *golf) addOpt(rs resultSetter, short, long, name, help string, defaultVal any, required bool, kind optType, settings []OptSetting) {
 	opt := golfOpt{
 		Short:        short,
 		Long:         long,
@@ -91,31 +413,69 @@ func (g *golf) addOpt(rs resultSetter, short, long, name, help string, defaultVa
 		ResultSetter: rs,
 		IsSet:        false,
 		Help:         help,
+		Key:          long,
+		Env:          envName(long),
+		Site:         callSite(),
 	}
-	if short != "" {
-		g.shorts[short] = &opt
-	}
-	if long != "" {
-		g.longs[long] = &opt
+	for _, set := range settings {
+		set(&opt)
 	}
-	g.all = append(g.all, &opt)
+	g.mustRegister(&opt)
 }
 
-func (g *golf) addOptTag(rs resultSetter, gtag string) error {
-	if !tagFullReg.MatchString(gtag) {
-		return fmt.Errorf("invalid golf tag format")
+func typeOfField(ft reflect.Type) (optType, bool) {
+	if reflect.PtrTo(ft).Implements(valueType) {
+		return optValue, true
+	}
+	if ft == durationType {
+		return optDuration, true
 	}
 	t, ok := map[reflect.Kind]optType{
 		reflect.Bool:      optBool,
 		reflect.Int:       optInt,
-		reflect.Float32:   optBool,
+		reflect.Int8:      optInt,
+		reflect.Int16:     optInt,
+		reflect.Int32:     optInt,
+		reflect.Int64:     optInt,
+		reflect.Uint:      optUint,
+		reflect.Uint8:     optUint,
+		reflect.Uint16:    optUint,
+		reflect.Uint32:    optUint,
+		reflect.Uint64:    optUint,
+		reflect.Float32:   optFloat,
+		reflect.Float64:   optFloat,
 		reflect.Array:     optArray,
 		reflect.Interface: optArray,
 		reflect.Slice:     optArray,
 		reflect.String:    optString,
-	}[rs.resultPtr.Kind()]
+		reflect.Map:       optMap,
+	}[ft.Kind()]
+	return t, ok
+}
+
+// supportedField reports whether a field of type ft can become an option.
+func supportedField(ft reflect.Type) bool {
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	t, ok := typeOfField(ft)
+	if t == optMap {
+		return ft.Key().Kind() == reflect.String
+	}
+	return ok
+}
+
+func (g *golf) addOptTag(rs resultSetter, gtag string, scope structScope, field string, presets ...OptSetting) error {
+	parts, err := parseTag(gtag)
+	if err != nil {
+		return err
+	}
+	t, ok := typeOfField(rs.Type())
 	if !ok {
-		return fmt.Errorf("unsupported type %s", rs.resultPtr.Kind())
+		return fmt.Errorf("unsupported type %s", rs.Type().Kind())
+	}
+	if t == optMap && rs.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("unsupported map key type %s", rs.Type().Key())
 	}
 
 	opt := golfOpt{
@@ -123,30 +483,52 @@ func (g *golf) addOptTag(rs resultSetter, gtag string) error {
 		Long:         "",
 		Name:         "",
 		Required:     false,
-		Default:      defaultValOfType(t),
+		Default:      nil,
 		Type:         t,
 		ResultSetter: rs,
 		IsSet:        false,
 		Help:         "",
 	}
-	matches := tagPartReg.FindAllStringSubmatch(gtag, -1)
-	for _, m := range matches {
-		if err := opt.fillByTag(m); err != nil {
-			return fmt.Errorf("parse tag [%s] failed: %v", m[0], err)
+	for _, set := range presets {
+		set(&opt)
+	}
+	failed := make([]string, 0)
+	for _, part := range parts {
+		if err := opt.fillByTag(part); err != nil {
+			failed = append(failed, fmt.Sprintf("parse tag [%s] failed: %v", part.Raw, err))
 		}
 	}
-	if opt.Short != "" {
-		g.shorts[opt.Short] = &opt
+	if len(failed) != 0 {
+		return fmt.Errorf("%s", strings.Join(failed, "; "))
+	}
+	if opt.Bare {
+		if err := opt.finishPositional(field); err != nil {
+			return err
+		}
+	}
+	if err := opt.applyDefault(); err != nil {
+		return err
 	}
 	if opt.Long != "" {
-		g.longs[opt.Long] = &opt
+		opt.Long = scope.long + opt.Long
+	}
+	for i := range opt.LongAliases {
+		opt.LongAliases[i] = scope.long + opt.LongAliases[i]
+	}
+	opt.Origin = fmt.Sprintf("field [%s%s]", scope.path, field)
+	opt.Env = scope.env + g.naming.Env(field)
+	opt.Key = opt.Long
+	if g.naming.Key != nil {
+		opt.Key = scope.key + g.naming.Key(field)
 	}
-	g.all = append(g.all, &opt)
 
-	return nil
+	return g.register(&opt)
 }
 
 func (o golfOpt) debugArg() string {
+	if o.Bare {
+		return o.Name
+	}
 	result := ""
 	if o.Short != "" {
 		result = "-" + o.Short
@@ -168,6 +550,8 @@ func (o golfOpt) debugValue() string {
 	switch o.Type {
 	case optInt:
 		return "int"
+	case optUint:
+		return "uint"
 	case optString:
 		return "string"
 	case optBool:
@@ -176,13 +560,44 @@ func (o golfOpt) debugValue() string {
 		return "float"
 	case optArray:
 		return "array"
+	case optCount:
+		return ""
+	case optMap:
+		return "key=value"
+	case optDuration:
+		return "duration"
+	case optValue:
+		return "value"
 	default:
 		return "<unknown>"
 	}
 }
 
+func (o golfOpt) debugArgAliases() string {
+	if !g.usageAliases {
+		return o.debugArg()
+	}
+	names := make([]string, 0, 2+len(o.ShortAliases)+len(o.LongAliases))
+	if o.Short != "" {
+		names = append(names, "-"+o.Short)
+	}
+	for _, short := range o.ShortAliases {
+		names = append(names, "-"+short)
+	}
+	if o.Long != "" {
+		names = append(names, "--"+o.Long)
+	}
+	for _, long := range o.LongAliases {
+		names = append(names, "--"+long)
+	}
+	return strings.Join(names, "/")
+}
+
 func (o golfOpt) debugArgValue() string {
-	arg := o.debugArg()
+	if o.Bare {
+		return o.Name
+	}
+	arg := o.debugArgAliases()
 	val := o.debugValue()
 	if arg == "" {
 		return val
@@ -197,12 +612,72 @@ func (o golfOpt) debugHelp() string {
 	if o.Required {
 		return "(required)"
 	} else {
-		return fmt.Sprintf("(default: \"%v\")", o.Default)
+		return fmt.Sprintf("(default: \"%v\")", o.display(fmt.Sprint(o.Default)))
+	}
+}
+
+const secretMask = "******"
+
+// display returns value as it may be shown to the user.
+func (o golfOpt) display(value string) string {
+	if o.Secret && value != "" && value != "<nil>" {
+		return secretMask
+	}
+	return value
+}
+
+// maskError hides value inside err when the option is secret.
+func (o golfOpt) maskError(err error, value string) error {
+	if !o.Secret || value == "" {
+		return err
+	}
+	return errors.New(strings.ReplaceAll(err.Error(), value, secretMask))
+}
+
+func (o *golfOpt) warnDeprecated() {
+	if !o.Deprecated || o.warned {
+		return
+	}
+	o.warned = true
+	_, _ = fmt.Fprintf(g.warn, "warning: arg<%s> is %s\n", o.debugArg(), o.deprecation())
+}
+
+func (o golfOpt) deprecation() string {
+	msg := "deprecated"
+	if o.Replacement != "" {
+		msg += fmt.Sprintf(", use %s instead", o.Replacement)
 	}
+	if o.DeprecateMsg != "" {
+		msg += ": " + o.DeprecateMsg
+	}
+	return msg
+}
+
+func (o golfOpt) usageLine() string {
+	if !o.Bare {
+		if o.Required {
+			return o.debugArgValue()
+		}
+		return "[" + o.debugArgValue() + "]"
+	}
+	if o.Type == optArray {
+		if o.MinItems > 0 {
+			return "<" + o.Name + ">..."
+		}
+		return "[" + o.Name + "...]"
+	}
+	if o.Required {
+		return "<" + o.Name + ">"
+	}
+	return "[" + o.Name + "]"
 }
 
 func (o golfOpt) Usage() string {
-	return fmt.Sprintf("  %-20s: %s %s", o.debugArgValue(), o.Help, o.debugHelp())
+	usage := fmt.Sprintf("  %-20s: %s %s", o.debugArgValue(), o.Help, o.debugHelp())
+	if o.Deprecated {
+		usage += " (" + o.deprecation() + ")"
+	}
+	return usage
 }
 
 func existInArray(arr []string, val string) bool {
@@ -232,8 +707,6 @@ func str2bool(value string) (bool, error) {
 func (o *golfOpt) Parse(value string) (err error) {
 	switch o.Type {
 	case optString:
-		fallthrough
-	case optBareString:
 		if ok := o.ResultSetter.SetValue(value); !ok {
 			return fmt.Errorf("arg<%s> result ptr is not string", o.debugArg())
 		}
@@ -241,18 +714,24 @@ func (o *golfOpt) Parse(value string) (err error) {
 	case optInt:
 		conv, err := strconv.Atoi(value)
 		if err != nil {
-			return fmt.Errorf("arg<%s> require int, got <%s>", o.debugArg(), value)
+			return fmt.Errorf("arg<%s> require int, got <%s>", o.debugArg(), o.display(value))
 		}
 		if ok := o.ResultSetter.SetValue(conv); !ok {
 			return fmt.Errorf("arg<%s> result ptr is not int", o.debugArg())
 		}
 		break
+	case optUint:
+		conv, err := str2value(o.ResultSetter.Type(), value)
+		if err != nil {
+			return fmt.Errorf("arg<%s> %v", o.debugArg(), o.maskError(err, value))
+		}
+		o.ResultSetter.Target().Set(conv)
 	case optBool:
 		result := false
 		if strings.HasPrefix(value, "-") {
 			result = true
 		} else if result, err = str2bool(value); err != nil {
-			return fmt.Errorf("arg<%s> %v", o.debugArg(), err.Error())
+			return fmt.Errorf("arg<%s> %v", o.debugArg(), o.maskError(err, value))
 		}
 		if ok := o.ResultSetter.SetValue(result); !ok {
 			return fmt.Errorf("arg<%s> result ptr is not bool", o.debugArg())
@@ -261,7 +740,7 @@ func (o *golfOpt) Parse(value string) (err error) {
 	case optFloat:
 		f, err := strconv.ParseFloat(value, 64)
 		if err != nil {
-			return fmt.Errorf("arg<%s> require float64, got <%s>", o.debugArg(), value)
+			return fmt.Errorf("arg<%s> require float64, got <%s>", o.debugArg(), o.display(value))
 		}
 
 		if ok := o.ResultSetter.SetValue(f); !ok {
@@ -269,8 +748,38 @@ func (o *golfOpt) Parse(value string) (err error) {
 		}
 		break
 	case optArray:
-		if ok := o.ResultSetter.AddValue(value); !ok {
-			return fmt.Errorf("arg<%s> result ptr is not []string", o.debugArg())
+		if err := o.parseArray(value); err != nil {
+			return err
+		}
+	case optDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("arg<%s> require duration, got <%s>", o.debugArg(), o.display(value))
+		}
+		if ok := o.ResultSetter.SetValue(d); !ok {
+			return fmt.Errorf("arg<%s> result ptr is not duration", o.debugArg())
+		}
+	case optValue:
+		v, ok := o.ResultSetter.Value()
+		if !ok {
+			return fmt.Errorf("arg<%s> result ptr is not Value", o.debugArg())
+		}
+		if err := v.Set(value); err != nil {
+			return fmt.Errorf("arg<%s> %v", o.debugArg(), o.maskError(err, value))
+		}
+	case optCount:
+		count := 0
+		if value == "" {
+			count = int(o.ResultSetter.Target().Int()) + 1
+		} else if count, err = strconv.Atoi(value); err != nil {
+			return fmt.Errorf("arg<%s> require int, got <%s>", o.debugArg(), o.display(value))
+		}
+		if ok := o.ResultSetter.SetValue(count); !ok {
+			return fmt.Errorf("arg<%s> result ptr is not int", o.debugArg())
+		}
+	case optMap:
+		if err := o.parseMap(value); err != nil {
+			return err
 		}
 	default:
 		return fmt.Errorf("arg<%s> unimplemented type %d", o.debugArg(), o.Type)
@@ -279,31 +788,185 @@ func (o *golfOpt) Parse(value string) (err error) {
 	return nil
 }
 
-func (o *golfOpt) fillByTag(m []string) error {
-	if len(m) != 10 {
-		return fmt.Errorf("invalid length %d", len(m))
-	}
-	key := ""
-	val := ""
-	if m[2] != "" {
-		// <key>: '<value>'[;]
-		// value in quote
-		key = strings.TrimSpace(m[2])
-		val = m[3]
-	} else if m[5] != "" {
-		// <key>;
-		// key only, value empty
-		key = strings.TrimSpace(m[5])
-	} else if m[7] != "" {
-		// <key>: <value>[;]
-		// key and value, without quote
-		key = strings.TrimSpace(m[7])
-		val = strings.TrimSpace(m[8])
-	} else if m[9] != "" {
-		// <key>
-		// key without value and colon
-		key = strings.TrimSpace(m[9])
+// applyDefault writes the tag default into the field through the same
+// conversion as parsed values, then records the effective default for Usage.
+func (o *golfOpt) applyDefault() error {
+	if o.hasDefault {
+		if err := o.Parse(o.defaultStr); err != nil {
+			return fmt.Errorf("invalid <default> val: %s", o.display(o.defaultStr))
+		}
+		o.IsSet = false
 	}
+	o.Default = o.currentValue()
+	return nil
+}
+
+func (o golfOpt) currentValue() any {
+	// checked first, Value and Target allocate optional pointers
+	if o.ResultSetter.optional && o.ResultSetter.resultPtr.IsNil() {
+		return nil
+	}
+	if o.Type == optValue {
+		if v, ok := o.ResultSetter.Value(); ok {
+			return v.String()
+		}
+	}
+	return o.ResultSetter.Target().Interface()
+}
+
+// finishPositional checks a positional declared by pos/nargs tag keys: a
+// scalar takes one value, "?" makes it optional, arrays take "*", "+" or N.
+func (o *golfOpt) finishPositional(field string) error {
+	if o.Short != "" || o.Long != "" {
+		return fmt.Errorf("positional cannot have short or long name")
+	}
+	if o.Name == "" {
+		o.Name = g.naming.Long(field)
+	}
+	if o.Type != optArray {
+		if o.MaxItems > 1 || o.MaxItems < 0 || o.Position == math.MaxInt32 {
+			return fmt.Errorf("positional with more than one value requires a slice")
+		}
+		o.Required = o.Required || o.MinItems > 0 || (o.MaxItems == 0 && !o.hasDefault)
+		o.MinItems, o.MaxItems = 0, 0
+	}
+	return nil
+}
+
+func (o *golfOpt) parseArray(value string) error {
+	target := o.ResultSetter.Target()
+	if target.Kind() != reflect.Slice {
+		return fmt.Errorf("arg<%s> result ptr is not slice", o.debugArg())
+	}
+	if !o.IsSet {
+		// values from the command line replace defaults
+		target.Set(reflect.MakeSlice(target.Type(), 0, 0))
+	}
+	items := []string{value}
+	if o.Sep != "" {
+		items = strings.Split(value, o.Sep)
+	}
+	elemType := target.Type().Elem()
+	for _, item := range items {
+		elem, err := str2value(elemType, item)
+		if err != nil {
+			return fmt.Errorf("arg<%s> %v", o.debugArg(), o.maskError(err, item))
+		}
+		if ok := o.ResultSetter.AddValue(elem); !ok {
+			return fmt.Errorf("arg<%s> result ptr is not []%s", o.debugArg(), elemType)
+		}
+	}
+	return nil
+}
+
+func (o *golfOpt) checkItems() error {
+	if o.Type != optArray || (o.MinItems <= 0 && o.MaxItems <= 0) {
+		return nil
+	}
+	n := 0
+	if !o.ResultSetter.optional || !o.ResultSetter.resultPtr.IsNil() {
+		n = o.ResultSetter.Target().Len()
+	}
+	if n < o.MinItems {
+		return fmt.Errorf("arg<%s> requires at least %d items, got %d", o.debugArg(), o.MinItems, n)
+	}
+	if o.MaxItems > 0 && n > o.MaxItems {
+		return fmt.Errorf("arg<%s> accepts at most %d items, got %d", o.debugArg(), o.MaxItems, n)
+	}
+	return nil
+}
+
+func (o *golfOpt) parseMap(value string) error {
+	m := *o.ResultSetter.Target()
+	if m.Kind() != reflect.Map {
+		return fmt.Errorf("arg<%s> result ptr is not map", o.debugArg())
+	}
+	if !o.IsSet || m.IsNil() {
+		// values from the command line replace defaults
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+	for _, pair := range strings.Split(value, ",") {
+		idx := strings.Index(pair, "=")
+		if idx == -1 {
+			return fmt.Errorf("arg<%s> require key=value, got <%s>", o.debugArg(), o.display(pair))
+		}
+		k, v := strings.TrimSpace(pair[:idx]), pair[idx+1:]
+		elem, err := str2value(m.Type().Elem(), v)
+		if err != nil {
+			return fmt.Errorf("arg<%s> key <%s>: %v", o.debugArg(), k, o.maskError(err, v))
+		}
+		key := reflect.ValueOf(k).Convert(m.Type().Key())
+		if m.MapIndex(key).IsValid() {
+			switch o.MapPolicy {
+			case MapKeepFirst:
+				continue
+			case MapReject:
+				return fmt.Errorf("arg<%s> duplicate key <%s>", o.debugArg(), k)
+			}
+		}
+		m.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+func str2value(t reflect.Type, s string) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+	if custom, ok := v.Addr().Interface().(Value); ok {
+		if err := custom.Set(s); err != nil {
+			return v, err
+		}
+		return v, nil
+	}
+	if t.Kind() == reflect.Ptr && t.Implements(valueType) {
+		v.Set(reflect.New(t.Elem()))
+		if err := v.Interface().(Value).Set(s); err != nil {
+			return v, err
+		}
+		return v, nil
+	}
+	if t == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return v, fmt.Errorf("require duration, got <%s>", s)
+		}
+		v.SetInt(int64(d))
+		return v, nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, t.Bits())
+		if err != nil {
+			return v, fmt.Errorf("require int, got <%s>", s)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, t.Bits())
+		if err != nil {
+			return v, fmt.Errorf("require uint, got <%s>", s)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return v, fmt.Errorf("require float, got <%s>", s)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := str2bool(s)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(b)
+	default:
+		return v, fmt.Errorf("unsupported type %s", t)
+	}
+	return v, nil
+}
+
+func (o *golfOpt) fillByTag(part tagPart) error {
+	key, val := part.Key, part.Val
 
 	switch strings.ToLower(key) {
 	case "s":
@@ -333,11 +996,9 @@ func (o *golfOpt) fillByTag(m []string) error {
 	case "d":
 		fallthrough
 	case "default":
-		defaultVal, err := str2optType(o.Type, val)
-		if err != nil {
-			return fmt.Errorf("invalid <default> val: %s", val)
-		}
-		o.Default = defaultVal
+		// applied once the whole tag is known, see applyDefault
+		o.defaultStr = val
+		o.hasDefault = true
 		break
 	case "h":
 		fallthrough
@@ -352,73 +1013,177 @@ func (o *golfOpt) fillByTag(m []string) error {
 			return fmt.Errorf("invalid <required> val: %s", val)
 		}
 		o.Required = req
+	case "hidden":
+		hidden, err := str2bool(val)
+		if err != nil {
+			return fmt.Errorf("invalid <hidden> val: %s", val)
+		}
+		o.Hidden = hidden
+	case "secret":
+		secret, err := str2bool(val)
+		if err != nil {
+			return fmt.Errorf("invalid <secret> val: %s", val)
+		}
+		o.Secret = secret
+	case "deprecated":
+		o.Deprecated = true
+		o.DeprecateMsg = val
+	case "a":
+		fallthrough
+	case "alias":
+		if val == "" {
+			return fmt.Errorf("<alias> cannot be empty")
+		}
+		o.addAliases(strings.Split(val, ","))
+	case "dup":
+		switch strings.ToLower(val) {
+		case "last":
+			o.MapPolicy = MapOverwrite
+		case "first":
+			o.MapPolicy = MapKeepFirst
+		case "error":
+			o.MapPolicy = MapReject
+		default:
+			return fmt.Errorf("invalid <dup> val: %s", val)
+		}
+	case "sep":
+		if val == "" {
+			return fmt.Errorf("<sep> cannot be empty")
+		}
+		o.Sep = val
+	case "min":
+		min, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("invalid <min> val: %s", val)
+		}
+		o.MinItems = min
+	case "max":
+		max, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("invalid <max> val: %s", val)
+		}
+		o.MaxItems = max
+	case "pos":
+		o.Bare, o.fixedPos = true, true
+		if strings.ToLower(val) == "rest" {
+			o.Position = math.MaxInt32
+			break
+		}
+		pos, err := strconv.Atoi(val)
+		if err != nil || pos < 0 {
+			return fmt.Errorf("invalid <pos> val: %s", val)
+		}
+		o.Position = pos
+	case "nargs":
+		o.Bare = true
+		switch val {
+		case "?":
+			o.MinItems, o.MaxItems = 0, 1
+		case "*":
+			o.MinItems, o.MaxItems = 0, -1
+		case "+":
+			o.MinItems, o.MaxItems = 1, -1
+		default:
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid <nargs> val: %s", val)
+			}
+			o.MinItems, o.MaxItems = n, n
+		}
+	case "file":
+		trim, err := parseTrimMode(val)
+		if err != nil {
+			return fmt.Errorf("invalid <file> val: %s", val)
+		}
+		o.FromFile = true
+		o.FileTrim = trim
+	case "filemax":
+		limit, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid <filemax> val: %s", val)
+		}
+		o.FileLimit = limit
+	case "count":
+		if o.Type != optInt {
+			return fmt.Errorf("<count> requires an int field")
+		}
+		o.Type = optCount
 	default:
 		return fmt.Errorf("invalid tag option <%s>", key)
 	}
 	return nil
 }
 
-func str2optType(t optType, s string) (any, error) {
-	switch t {
-	case optInt:
-		if i, err := strconv.Atoi(s); err != nil {
-			return nil, fmt.Errorf("<%s> not valid int", s)
-		} else {
-			return i, nil
-		}
-	case optBool:
-		if b, err := str2bool(s); err != nil {
-			return false, err
-		} else {
-			return b, nil
+func lookupOpt(k string) *golfOpt {
+	if strings.HasPrefix(k, "--") {
+		return g.longs[strings.TrimPrefix(k, "--")]
+	}
+	return g.shorts[strings.TrimPrefix(k, "-")]
+}
+
+// reservedBool returns the flag golf added itself under one of the names by
+// an earlier ParseOSArgs, cleared for the next Parse, or nil.
+func (g *golf) reservedBool(short, long string) *bool {
+	for _, opt := range []*golfOpt{g.shorts[short], g.longs[long]} {
+		if opt != nil && opt.reserved {
+			flag := opt.ResultSetter.Target().Addr().Interface().(*bool)
+			*flag = false
+			return flag
 		}
-	case optString:
-		return s, nil
-	case optFloat:
-		if f, err := strconv.ParseFloat(s, 64); err != nil {
-			return nil, fmt.Errorf("<%s> not valid float", s)
-		} else {
-			return f, nil
+	}
+	return nil
+}
+
+// lookupBundle splits "-vvx" into its shorts when it is not a short on its
+// own and every letter is a flag which takes no value.
+func lookupBundle(k string) []string {
+	if strings.HasPrefix(k, "--") || len(k) < 3 || lookupOpt(k) != nil {
+		return nil
+	}
+	shorts := make([]string, 0, len(k)-1)
+	for _, c := range strings.TrimPrefix(k, "-") {
+		opt, ok := g.shorts[string(c)]
+		if !ok || (opt.Type != optBool && opt.Type != optCount) {
+			return nil
 		}
-	default:
-		return nil, fmt.Errorf("cannot parse type %v from string", t)
+		shorts = append(shorts, string(c))
 	}
+	return shorts
 }
 
-func defaultValOfType(t optType) any {
-	switch t {
-	case optInt:
-		return 0
-	case optBool:
-		return false
-	case optBareString:
-		fallthrough
-	case optString:
-		return ""
-	case optFloat:
-		return 0.0
-	case optBareArray:
-		fallthrough
-	case optArray:
-		return make([]string, 0)
+// parseArg parses a value given on the command line.
+func (o *golfOpt) parseArg(value string, src Source) error {
+	if g.bootstrap != nil && !g.bootstrap[o] {
+		return nil
 	}
-	return nil
+	o.warnDeprecated()
+	resolved, ok := o.bootValues[value]
+	if !ok {
+		var err error
+		if resolved, err = o.resolveValue(value); err != nil {
+			return err
+		}
+		if g.bootstrap != nil {
+			o.keepBootValue(value, resolved)
+		}
+	}
+	return o.setFrom(src, resolved)
 }
 
-func parseKV(k, v string) error {
+func parseKV(k, v string, src Source) error {
 	if strings.HasPrefix(k, "--") {
 		key := strings.TrimPrefix(k, "--")
 		if opt, ok := g.longs[key]; ok {
-			if err := opt.Parse(v); err != nil {
+			if err := opt.parseArg(v, src); err != nil {
 				return err
 			}
-		} else {
+		} else if g.bootstrap == nil {
 			return fmt.Errorf("unrecognized arg %v", k)
 		}
 	} else {
 		key := strings.TrimPrefix(k, "-")
 		if opt, ok := g.shorts[key]; ok {
-			if err := opt.Parse(v); err != nil {
+			if err := opt.parseArg(v, src); err != nil {
 				return err
 			}
 		}
@@ -426,99 +1191,200 @@ func parseKV(k, v string) error {
 	return nil
 }
 
-func String(short, long, name, help string, defaultVal string) *string {
+func String(short, long, name, help string, defaultVal string, opts ...OptSetting) *string {
 	result := defaultVal
 	resultValue := reflect.ValueOf(&result).Elem()
 	setter := resultSetter{resultPtr: &resultValue}
-	g.addOpt(setter, short, long, name, help, defaultVal, false, optString)
+	g.addOpt(setter, short, long, name, help, defaultVal, false, optString, opts)
 	return &result
 }
 
-func MustString(short, long, name, help string) *string {
+func MustString(short, long, name, help string, opts ...OptSetting) *string {
 	result := ""
 	resultValue := reflect.ValueOf(&result).Elem()
 	setter := resultSetter{resultPtr: &resultValue}
-	g.addOpt(setter, short, long, name, help, "", true, optString)
+	g.addOpt(setter, short, long, name, help, "", true, optString, opts)
 	return &result
 }
 
-func Int(short, long, name, help string, defaultVal int) *int {
+func Int(short, long, name, help string, defaultVal int, opts ...OptSetting) *int {
 	result := defaultVal
 	resultValue := reflect.ValueOf(&result).Elem()
 	setter := resultSetter{resultPtr: &resultValue}
-	g.addOpt(setter, short, long, name, help, result, false, optInt)
+	g.addOpt(setter, short, long, name, help, result, false, optInt, opts)
 	return &result
 }
 
-func MustInt(short, long, name, help string) *int {
+func MustInt(short, long, name, help string, opts ...OptSetting) *int {
 	result := 0
 	resultValue := reflect.ValueOf(&result).Elem()
 	setter := resultSetter{resultPtr: &resultValue}
-	g.addOpt(setter, short, long, name, help, result, true, optInt)
+	g.addOpt(setter, short, long, name, help, result, true, optInt, opts)
 	return &result
 }
 
-func Bool(short, long, name, help string, defaultVal bool) *bool {
+func Bool(short, long, name, help string, defaultVal bool, opts ...OptSetting) *bool {
 	result := defaultVal
 	resultValue := reflect.ValueOf(&result).Elem()
 	setter := resultSetter{resultPtr: &resultValue}
-	g.addOpt(setter, short, long, name, help, result, false, optBool)
+	g.addOpt(setter, short, long, name, help, result, false, optBool, opts)
 	return &result
 }
 
-func MustBool(short, long, name, help string) *bool {
+func MustBool(short, long, name, help string, opts ...OptSetting) *bool {
 	result := false
 	resultValue := reflect.ValueOf(&result).Elem()
 	setter := resultSetter{resultPtr: &resultValue}
-	g.addOpt(setter, short, long, name, help, false, true, optBool)
+	g.addOpt(setter, short, long, name, help, false, true, optBool, opts)
+	return &result
+}
+
+func Count(short, long, name, help string, opts ...OptSetting) *int {
+	result := 0
+	resultValue := reflect.ValueOf(&result).Elem()
+	setter := resultSetter{resultPtr: &resultValue}
+	g.addOpt(setter, short, long, name, help, result, false, optCount, opts)
 	return &result
 }
 
-func Array(short, long, name, help string) *[]string {
+func Map(short, long, name, help string, opts ...OptSetting) *map[string]string {
+	result := make(map[string]string)
+	resultValue := reflect.ValueOf(&result).Elem()
+	setter := resultSetter{resultPtr: &resultValue}
+	g.addOpt(setter, short, long, name, help, result, false, optMap, opts)
+	return &result
+}
+
+func Array(short, long, name, help string, opts ...OptSetting) *[]string {
 	result := make([]string, 0)
 	resultValue := reflect.ValueOf(&result).Elem()
 	setter := resultSetter{resultPtr: &resultValue}
-	g.addOpt(setter, short, long, name, help, result, false, optArray)
+	g.addOpt(setter, short, long, name, help, result, false, optArray, opts)
 	return &result
 }
 
-func BareArray(name, help string) *[]string {
+func IntArray(short, long, name, help string, opts ...OptSetting) *[]int {
+	result := make([]int, 0)
+	resultValue := reflect.ValueOf(&result).Elem()
+	setter := resultSetter{resultPtr: &resultValue}
+	g.addOpt(setter, short, long, name, help, result, false, optArray, opts)
+	return &result
+}
+
+func FloatArray(short, long, name, help string, opts ...OptSetting) *[]float64 {
+	result := make([]float64, 0)
+	resultValue := reflect.ValueOf(&result).Elem()
+	setter := resultSetter{resultPtr: &resultValue}
+	g.addOpt(setter, short, long, name, help, result, false, optArray, opts)
+	return &result
+}
+
+func BoolArray(short, long, name, help string, opts ...OptSetting) *[]bool {
+	result := make([]bool, 0)
+	resultValue := reflect.ValueOf(&result).Elem()
+	setter := resultSetter{resultPtr: &resultValue}
+	g.addOpt(setter, short, long, name, help, result, false, optArray, opts)
+	return &result
+}
+
+func DurationArray(short, long, name, help string, opts ...OptSetting) *[]time.Duration {
+	result := make([]time.Duration, 0)
+	resultValue := reflect.ValueOf(&result).Elem()
+	setter := resultSetter{resultPtr: &resultValue}
+	g.addOpt(setter, short, long, name, help, result, false, optArray, opts)
+	return &result
+}
+
+func Duration(short, long, name, help string, defaultVal time.Duration, opts ...OptSetting) *time.Duration {
+	result := defaultVal
+	resultValue := reflect.ValueOf(&result).Elem()
+	setter := resultSetter{resultPtr: &resultValue}
+	g.addOpt(setter, short, long, name, help, result, false, optDuration, opts)
+	return &result
+}
+
+func Var(v Value, short, long, name, help string, opts ...OptSetting) {
+	resultValue := reflect.ValueOf(v)
+	setter := resultSetter{resultPtr: &resultValue}
+	g.addOpt(setter, short, long, name, help, v.String(), false, optValue, opts)
+}
+
+func BareInt(name, help string, opts ...OptSetting) *int {
+	result := 0
+	resultValue := reflect.ValueOf(&result).Elem()
+	setter := resultSetter{resultPtr: &resultValue}
+	g.addBare(setter, name, help, result, optInt, opts)
+	return &result
+}
+
+func BareFloat(name, help string, opts ...OptSetting) *float64 {
+	result := 0.0
+	resultValue := reflect.ValueOf(&result).Elem()
+	setter := resultSetter{resultPtr: &resultValue}
+	g.addBare(setter, name, help, result, optFloat, opts)
+	return &result
+}
+
+func BareArray(name, help string, opts ...OptSetting) *[]string {
 	result := make([]string, 0)
 	resultValue := reflect.ValueOf(&result).Elem()
 	setter := resultSetter{resultPtr: &resultValue}
-	g.addOpt(setter, "", "", name, help, result, false, optBareArray)
+	g.addBare(setter, name, help, result, optArray, opts)
 	return &result
 }
-func BareString(name, help string) *string {
+func BareString(name, help string, opts ...OptSetting) *string {
 	result := ""
 	resultValue := reflect.ValueOf(&result).Elem()
 	setter := resultSetter{resultPtr: &resultValue}
-	g.addOpt(setter, "", "", name, help, result, true, optBareString)
+	g.addBare(setter, name, help, result, optString, opts)
 	return &result
 }
 
 func Reset() {
 	g = golf{
-		shorts: map[string]*golfOpt{},
-		longs:  map[string]*golfOpt{},
-		all:    make([]*golfOpt, 0),
+		shorts:      map[string]*golfOpt{},
+		longs:       map[string]*golfOpt{},
+		all:         make([]*golfOpt, 0),
+		warn:        os.Stderr,
+		naming:      defaultNaming(),
+		stdin:       os.Stdin,
+		explainFlag: defaultExplainFlag,
+		explainOut:  os.Stdout,
+		exit:        os.Exit,
 	}
 }
 
+func SetWarningOutput(w io.Writer) {
+	g.warn = w
+}
+
+// SetTagFallback lets ParseStruct take fields without golf tag by the names
+// in their json/yaml/... tags, in the order of keys, or in a flag tag.
+// Such fields are skipped when golf cannot parse their type.
+func SetTagFallback(keys ...string) {
+	g.fallbackTags = keys
+}
+
+func SetUsageAliases(show bool) {
+	g.usageAliases = show
+}
+
 func Usage(executable string) string {
 	builder := strings.Builder{}
 	builder.WriteString("Usage:\n")
 	builder.WriteString(fmt.Sprintf("  %s", executable))
+	shown := make([]*golfOpt, 0, len(g.all))
 	for _, opt := range g.all {
-		if opt.Required {
-			builder.WriteString(fmt.Sprintf(" %s", opt.debugArgValue()))
-		} else {
-			builder.WriteString(fmt.Sprintf(" [%s]", opt.debugArgValue()))
+		if !opt.Hidden {
+			shown = append(shown, opt)
 		}
 	}
+	for _, opt := range shown {
+		builder.WriteString(" " + opt.usageLine())
+	}
 	builder.WriteString("\n\n")
-	msg := make([]string, len(g.all))
-	for i, opt := range g.all {
+	msg := make([]string, len(shown))
+	for i, opt := range shown {
 		msg[i] = opt.Usage()
 	}
 	builder.WriteString(fmt.Sprintf("Arguments:\n%s\n", strings.Join(msg, "\n")))
@@ -531,66 +1397,174 @@ func Parse(args []string) (err error) {
 			err = fmt.Errorf("golf parse panic: %v", reflect.TypeOf(r).Elem().Name())
 		}
 	}()
-	bares := make([]string, 0)
-	key := ""
-	for _, entry := range args {
-		if key == "" {
-			if strings.HasPrefix(entry, "-") {
-				if idx := strings.Index(entry, "="); idx != -1 {
-					k, v := entry[:idx], entry[idx+1:]
-					if err := parseKV(k, v); err != nil {
-						return err
+	if g.strict {
+		if err := Validate(); err != nil {
+			return err
+		}
+	}
+	g.resetBootstrap()
+	bares, err := g.parseArgs(args)
+	g.forgetBootValues()
+	if err != nil {
+		return err
+	}
+	if err := g.parseBares(bares); err != nil {
+		return err
+	}
+	if err := g.applyEnv(); err != nil {
+		return err
+	}
+	if err := g.applyConfigs(); err != nil {
+		return err
+	}
+
+	g.assignLazies()
+	for _, opt := range g.all {
+		if opt.Required && !opt.IsSet {
+			if opt.Bare {
+				return fmt.Errorf("missing argument: %s", opt.Name)
+			}
+			return fmt.Errorf("missing argument: %s %s", opt.debugArg(), opt.debugValue())
+		}
+		if err := opt.checkItems(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseArgs sets the options given in args and returns the positionals,
+// everything after "--" is taken as positional.
+func (g *golf) parseArgs(args []string) ([]argToken, error) {
+	tokens := make([]argToken, len(args))
+	for i, arg := range args {
+		tokens[i] = argToken{val: arg, index: i}
+	}
+	bares := make([]argToken, 0)
+	key, keySrc := "", Source{}
+	for i := 0; i < len(tokens); i++ {
+		entry := tokens[i].val
+		src := tokens[i].source()
+		if key == "" && entry == "--" {
+			bares = append(bares, tokens[i+1:]...)
+			break
+		}
+		if key == "" && isResponseFile(entry) {
+			// only where a new argument starts, "--opt @x" keeps its value
+			expanded, err := g.expandResponse(tokens[i])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens[:i], append(expanded, tokens[i+1:]...)...)
+			i--
+			continue
+		}
+		if key != "" {
+			opt := lookupOpt(key)
+			if strings.HasPrefix(entry, "-") && (opt == nil && g.bootstrap != nil || opt != nil && opt.Type == optBool) {
+				// a bare bool flag, or an unknown one while bootstrapping,
+				// followed by another flag
+				if err := parseKV(key, "", keySrc); err != nil {
+					return nil, err
+				}
+				key = ""
+			} else if err := parseKV(key, entry, keySrc); err != nil {
+				return nil, err
+			} else {
+				key = ""
+				continue
+			}
+		}
+		if strings.HasPrefix(entry, "-") {
+			if idx := strings.Index(entry, "="); idx != -1 {
+				k, v := entry[:idx], entry[idx+1:]
+				if err := parseKV(k, v, src); err != nil {
+					return nil, err
+				}
+			} else if opt := lookupOpt(entry); opt != nil && opt.Type == optCount {
+				if err := parseKV(entry, "", src); err != nil {
+					return nil, err
+				}
+			} else if bundle := lookupBundle(entry); bundle != nil {
+				for _, short := range bundle {
+					if err := parseKV("-"+short, "", src); err != nil {
+						return nil, err
 					}
-					key = ""
-				} else {
-					key = entry
 				}
 			} else {
-				bares = append(bares, entry)
+				key, keySrc = entry, src
 			}
-		} else if err := parseKV(key, entry); err != nil {
-			return err
 		} else {
-			key = ""
+			bares = append(bares, tokens[i])
 		}
 	}
 	if key != "" {
-		if strings.HasPrefix(key, "-") {
-			if err := parseKV(key, ""); err != nil {
-				return err
-			}
-		} else {
-			bares = append(bares, key)
+		if err := parseKV(key, "", keySrc); err != nil {
+			return nil, err
 		}
 	}
+	return bares, nil
+}
 
-	for _, opt := range g.all {
-		if opt.Type == optBareString {
-			if len(bares) != 0 {
-				if err := opt.Parse(bares[0]); err != nil {
-					return err
-				}
-				bares = bares[1:]
+// parseBares hands out positional values in order, each positional takes as
+// many as it may while leaving enough for the minimum of those after it.
+func (g *golf) parseBares(bares []argToken) error {
+	positionals := g.positionals()
+	for i, opt := range positionals {
+		after := 0
+		for _, next := range positionals[i+1:] {
+			after += next.minArgs()
+		}
+		take := len(bares) - after
+		if take < opt.minArgs() {
+			// not enough for everyone, earlier positionals come first
+			take = opt.minArgs()
+		}
+		if max := opt.maxArgs(); max >= 0 && take > max {
+			take = max
+		}
+		if take > len(bares) {
+			take = len(bares)
+		}
+		if take <= 0 {
+			continue
+		}
+		for _, bare := range bares[:take] {
+			if err := opt.setFrom(bare.source(), bare.val); err != nil {
+				return err
 			}
 		}
+		bares = bares[take:]
 	}
-	for _, opt := range g.all {
-		if opt.Type == optBareArray {
-			if ok := opt.ResultSetter.SetValue(bares); !ok {
-				return fmt.Errorf("arg<%s> result ptr is not []string", opt.debugArg())
-			}
-			opt.IsSet = true
-			bares = make([]string, 0)
+	if len(bares) != 0 {
+		extra := make([]string, len(bares))
+		for i, bare := range bares {
+			extra[i] = bare.val
 		}
+		return fmt.Errorf("too many arguments: %s", strings.Join(extra, " "))
 	}
+	return nil
+}
 
-	for _, opt := range g.all {
-		if opt.Required && !opt.IsSet {
-			return fmt.Errorf("missing argument: %s %s", opt.debugArg(), opt.debugValue())
+func (o golfOpt) minArgs() int {
+	if o.Type != optArray {
+		if o.Required {
+			return 1
 		}
+		return 0
 	}
+	return o.MinItems
+}
 
-	return nil
+func (o golfOpt) maxArgs() int {
+	if o.Type != optArray {
+		return 1
+	}
+	if o.MaxItems <= 0 {
+		return -1
+	}
+	return o.MaxItems
 }
 
 func ParseStruct(args []string, v interface{}) (err error) {
@@ -603,37 +1577,253 @@ func ParseStruct(args []string, v interface{}) (err error) {
 	if vpt.Kind() != reflect.Ptr {
 		return fmt.Errorf("golf parse struct expects a pointer to struct")
 	}
-	vt := vpt.Elem().Type()
-	vv := vpt.Elem()
+	if vpt.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("golf parse struct expects a pointer to struct")
+	}
+	first := len(g.all)
+	if err := g.addStruct(vpt.Elem(), structScope{}); err != nil {
+		return err
+	}
+	if g.autoShort {
+		g.assignShorts(g.all[first:])
+	}
+
+	return Parse(args)
+}
+
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !reflect.PtrTo(t).Implements(valueType)
+}
+
+// structScope carries the name prefixes of a nested struct down to its fields.
+type structScope struct {
+	long string
+	env  string
+	key  string
+	path string
+}
+
+// nest extends the scope by a named struct field, the long prefix being
+// "<field>." unless the field carries its own prefix tag.
+func (s structScope) nest(gtag, field, name string) (structScope, error) {
+	if name == "" {
+		name = g.naming.Long(field)
+	}
+	long := name + "."
+	parts, err := parseTag(gtag)
+	if err != nil {
+		return s, err
+	}
+	for _, part := range parts {
+		if strings.ToLower(part.Key) != "prefix" {
+			return s, fmt.Errorf("invalid tag option <%s> for struct", part.Key)
+		}
+		long = part.Val
+	}
+	nested := structScope{
+		long: s.long + long,
+		env:  s.env + g.naming.Env(field) + "_",
+		path: s.path + field + ".",
+	}
+	if g.naming.Key != nil {
+		nested.key = s.key + g.naming.Key(field) + "."
+	}
+	return nested, nil
+}
+
+// addStruct registers the tagged fields of vv, descending into nested
+// structs: embedded ones share the scope of their parent.
+func (g *golf) addStruct(vv reflect.Value, scope structScope) error {
+	vt := vv.Type()
+	if g.walking[vt] {
+		return fmt.Errorf("golf struct %s nests itself at [%s]", vt, strings.TrimSuffix(scope.path, "."))
+	}
+	if g.walking == nil {
+		g.walking = map[reflect.Type]bool{}
+	}
+	g.walking[vt] = true
+	defer delete(g.walking, vt)
 	for i := 0; i < vt.NumField(); i++ {
 		st := vt.Field(i)
 		sv := vv.Field(i)
 		if !sv.CanSet() {
+			if _, ok := st.Tag.Lookup("golf"); ok && g.strict {
+				g.problems = append(g.problems, fmt.Sprintf("field [%s%s] is unexported", scope.path, st.Name))
+			}
 			continue
 		}
 		gtag, ok := st.Tag.Lookup("golf")
-		if !ok {
+		var presets []OptSetting
+		fallback, help, skip := "", "", false
+		if !ok && len(g.fallbackTags) != 0 {
+			fallback, help, skip = g.fallbackTag(st)
+			if skip {
+				continue
+			}
+			if fallback != "" {
+				gtag = "n:" + st.Name
+				presets = append(presets, fallbackSetting(fallback, help))
+			}
+		}
+		if isNestedStruct(st.Type) && (ok || fallback != "" || st.Anonymous) {
+			nested := scope
+			if !st.Anonymous {
+				var err error
+				if !ok {
+					gtag = ""
+				}
+				if nested, err = scope.nest(gtag, st.Name, fallback); err != nil {
+					if err := g.fail(fmt.Errorf("golf parse tag of [%s%s] failed: %v", scope.path, st.Name, err)); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			if err := g.addNested(sv, nested); err != nil {
+				return err
+			}
+			continue
+		}
+		if !ok && fallback == "" {
+			continue
+		}
+		if !ok && !supportedField(st.Type) {
+			// only named by another library, not meant for golf
 			continue
 		}
 		if gtag == "" {
-			snake := nameFirstCap.ReplaceAllString(st.Name, "${1}_${2}")
-			snake = strings.ToLower(nameAllCap.ReplaceAllString(snake, "${1}_${2}"))
-			gtag = fmt.Sprintf("l:%s;n:%s", snake, st.Name)
+			gtag = fmt.Sprintf("l:%s;n:%s", g.naming.Long(st.Name), st.Name)
 		}
-		setter := resultSetter{resultPtr: &sv}
+		setter := resultSetter{resultPtr: &sv, optional: st.Type.Kind() == reflect.Ptr}
 
-		if err := g.addOptTag(setter, gtag); err != nil {
-			return fmt.Errorf("golf parse tag of [%s] failed: %v", st.Name, err)
+		if err := g.addOptTag(setter, gtag, scope, st.Name, presets...); err != nil {
+			if err := g.fail(fmt.Errorf("golf parse tag of [%s%s] failed: %v", scope.path, st.Name, err)); err != nil {
+				return err
+			}
 		}
 	}
+	return nil
+}
 
-	return Parse(args)
+// fallbackTag derives the long name and help of a field without golf tag
+// from the tags of other libraries, skip is set for fields tagged "-".
+func (g *golf) fallbackTag(st reflect.StructField) (long, help string, skip bool) {
+	keys := append(append([]string{}, g.fallbackTags...), "flag")
+	for _, key := range keys {
+		tag, ok := st.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+		long = strings.TrimSpace(strings.Split(tag, ",")[0])
+		if long == "-" {
+			return "", "", true
+		}
+		if long == "" {
+			long = g.naming.Long(st.Name)
+		}
+		break
+	}
+	for _, key := range []string{"usage", "help", "description"} {
+		if tag, ok := st.Tag.Lookup(key); ok {
+			help = tag
+			break
+		}
+	}
+	return long, help, false
 }
 
+func fallbackSetting(long, help string) OptSetting {
+	return func(o *golfOpt) {
+		o.Long = long
+		o.Help = help
+	}
+}
+
+func (g *golf) addNested(sv reflect.Value, scope structScope) error {
+	if sv.Kind() != reflect.Ptr {
+		return g.addStruct(sv, scope)
+	}
+	if !sv.IsNil() {
+		return g.addStruct(sv.Elem(), scope)
+	}
+	tmp := reflect.New(sv.Type().Elem())
+	first := len(g.all)
+	if err := g.addStruct(tmp.Elem(), scope); err != nil {
+		return err
+	}
+	g.lazies = append(g.lazies, lazyStruct{field: sv, tmp: tmp, opts: g.all[first:]})
+	return nil
+}
+
+func (g *golf) assignLazies() {
+	for _, lazy := range g.lazies {
+		for _, opt := range lazy.opts {
+			if opt.IsSet {
+				lazy.field.Set(lazy.tmp)
+				break
+			}
+		}
+	}
+}
+
+func findOpt(name string) *golfOpt {
+	if strings.HasPrefix(name, "-") {
+		return lookupOpt(name)
+	}
+	if opt, ok := g.longs[name]; ok {
+		return opt
+	}
+	if opt, ok := g.shorts[name]; ok {
+		return opt
+	}
+	for _, opt := range g.all {
+		if opt.Name == name {
+			return opt
+		}
+	}
+	return nil
+}
+
+// IsSet reports whether the option given by its long, short or value name
+// was set by the last Parse, defaults do not count.
+func IsSet(name string) bool {
+	opt := findOpt(name)
+	return opt != nil && opt.IsSet
+}
+
+// Changed is the same as IsSet, under the name used by other flag packages:
+// an option given with its default value counts as changed.
+func Changed(name string) bool {
+	return IsSet(name)
+}
+
+// ParseOSArgs parses os.Args with -h/--help added, and reports whether help
+// was asked for. A name the program already uses, e.g. -h for --host, is left
+// to the program; with both taken help is never reported.
 func ParseOSArgs() (bool, error) {
-	help := Bool("h", "help", "", "Show this message", false)
-	if err := Parse(os.Args[1:]); err != nil {
-		return *help, err
+	help := g.reservedBool("h", "help")
+	if help == nil {
+		// programs may use -h or --help for something else, e.g. --host
+		short, long := "h", "help"
+		if _, ok := g.shorts[short]; ok {
+			short = ""
+		}
+		if _, ok := g.longs[long]; ok {
+			long = ""
+		}
+		help = new(bool)
+		if short != "" || long != "" {
+			help = Bool(short, long, "", "Show this message", false)
+			g.all[len(g.all)-1].reserved = true
+		}
+	}
+	explain := g.addExplainFlag()
+	err := Parse(os.Args[1:])
+	if *explain {
+		g.runExplain(err)
 	}
-	return *help, nil
+	return *help, err
 }
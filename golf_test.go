@@ -1,10 +1,14 @@
 package golf
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEmpty(t *testing.T) {
@@ -12,10 +16,13 @@ func TestEmpty(t *testing.T) {
 	args := []string{
 		"a", "b", "c",
 	}
-	err := Parse(args)
-	if err != nil {
+	if err := Parse([]string{}); err != nil {
 		t.Fatal(err)
 	}
+	// without declared positionals every bare argument is one too many
+	if err := Parse(args); err == nil || err.Error() != "too many arguments: a b c" {
+		t.Fatalf("Expect too many arguments, got %v", err)
+	}
 }
 
 func TestString(t *testing.T) {
@@ -64,7 +71,7 @@ func TestUsage(t *testing.T) {
 	_ = BareString("repeats", "Command Repeat Times")
 	_ = BareArray("remains", "Remaining Args")
 	expect := `Usage:
-  ./test_exec [-c/--conf conf_file] -p pid_file --key string [--token string] command repeats [remains]
+  ./test_exec [-c/--conf conf_file] -p pid_file --key string [--token string] <command> <repeats> [remains...]
 
 Arguments:
   -c/--conf conf_file : Config File (default: "conf.yaml")
@@ -180,6 +187,22 @@ func TestParseOSArgs(t *testing.T) {
 	} else if *d != true {
 		t.Fatalf("Expect d == true, got false")
 	}
+
+	// the flags golf added are reused by a second call
+	for i, expect := range []bool{true, false, true} {
+		os.Args = []string{"./test_exec"}
+		if expect {
+			os.Args = append(os.Args, "--help")
+		}
+		if help, err := ParseOSArgs(); err != nil {
+			t.Fatalf("Call %d: %v", i, err)
+		} else if help != expect {
+			t.Fatalf("Call %d: expect help %v, got %v", i, expect, help)
+		}
+	}
+	if n := len(g.all); n != 3 {
+		t.Fatalf("Expect help and explain added once, got %d options", n)
+	}
 }
 
 func TestStruct(t *testing.T) {
@@ -221,3 +244,677 @@ func TestStruct(t *testing.T) {
 	}
 	t.Log(Usage("./golf_test"))
 }
+
+func TestHiddenDeprecated(t *testing.T) {
+	Reset()
+	warn := bytes.Buffer{}
+	SetWarningOutput(&warn)
+	type Config struct {
+		Secret string `golf:"long:secret;hidden"`
+		Old    int    `golf:"long:old;deprecated:'will be removed in v2'"`
+	}
+	var conf Config
+	port := Int("p", "port", "port", "Port", 80, Deprecated("--listen", ""))
+	args := []string{
+		"--secret", "s3", "--old", "1", "-p", "8080", "--port", "8081",
+	}
+	if err := ParseStruct(args, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Secret != "s3" || conf.Old != 1 || *port != 8081 {
+		t.Fatalf("Got %v, %d, expect s3, 1, 8081", conf, *port)
+	}
+	expectWarn := "warning: arg<--old> is deprecated: will be removed in v2\n" +
+		"warning: arg<-p/--port> is deprecated, use --listen instead\n"
+	if warn.String() != expectWarn {
+		t.Fatalf("Expect warnings <%s>, got <%s>", expectWarn, warn.String())
+	}
+	expectUsage := `Usage:
+  ./test_exec [-p/--port port] [--old int]
+
+Arguments:
+  -p/--port port      : Port (default: "80") (deprecated, use --listen instead)
+  --old int           :  (default: "0") (deprecated: will be removed in v2)
+`
+	if str := Usage("./test_exec"); str != expectUsage {
+		t.Fatalf("Expect usage <%s>, got <%s>", expectUsage, str)
+	}
+}
+
+func TestAlias(t *testing.T) {
+	Reset()
+	type Config struct {
+		Color string `golf:"short:c;long:color;alias:'--colour,-C'"`
+	}
+	var conf Config
+	out := String("o", "output", "file", "Output file", "", Alias("out", "--dest", "O"))
+	args := []string{
+		"--colour", "red", "--dest", "a.txt",
+	}
+	if err := ParseStruct(args, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Color != "red" || *out != "a.txt" {
+		t.Fatalf("Expect red, a.txt, got %s, %s", conf.Color, *out)
+	}
+	Reset()
+	out = String("o", "output", "file", "Output file", "", Alias("out", "--dest", "O"))
+	if err := Parse([]string{"-O", "b.txt"}); err != nil {
+		t.Fatal(err)
+	} else if *out != "b.txt" {
+		t.Fatalf("Expect b.txt, got %s", *out)
+	}
+	SetUsageAliases(true)
+	expect := `Usage:
+  ./test_exec [-o/-O/--output/--out/--dest file]
+
+Arguments:
+  -o/-O/--output/--out/--dest file: Output file (default: "")
+`
+	if str := Usage("./test_exec"); str != expect {
+		t.Fatalf("Expect usage <%s>, got <%s>", expect, str)
+	}
+}
+
+func TestCount(t *testing.T) {
+	Reset()
+	type Config struct {
+		Quiet int `golf:"short:q;long:quiet;count"`
+	}
+	var conf Config
+	verbose := Count("v", "verbose", "", "Verbosity")
+	debug := Bool("d", "debug", "", "Debug", false)
+	files := BareArray("files", "Files")
+	args := []string{
+		"-vvd", "a.txt", "--verbose", "-v", "b.txt", "-qq", "--quiet",
+	}
+	if err := ParseStruct(args, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if *verbose != 4 || !*debug || conf.Quiet != 3 || !arrayEqual(*files, []string{"a.txt", "b.txt"}) {
+		t.Fatalf("Got %d, %v, %d, %v", *verbose, *debug, conf.Quiet, *files)
+	}
+
+	Reset()
+	verbose = Count("v", "verbose", "", "Verbosity")
+	if err := Parse([]string{"-v", "-v=3"}); err != nil {
+		t.Fatal(err)
+	} else if *verbose != 3 {
+		t.Fatalf("Expect 3, got %d", *verbose)
+	}
+}
+
+func TestMap(t *testing.T) {
+	Reset()
+	type Config struct {
+		Limits map[string]int `golf:"short:m;long:limit;dup:error"`
+	}
+	conf := Config{
+		Limits: map[string]int{"preset": 1},
+	}
+	labels := Map("l", "label", "", "Labels")
+	firsts := Map("", "first", "", "", DuplicateKeys(MapKeepFirst))
+	args := []string{
+		"--label", "env=prod", "-l=team=core,env=dev", "--first", "a=1,a=2",
+		"-m", "cpu=2,mem=512",
+	}
+	if err := ParseStruct(args, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if len(*labels) != 2 || (*labels)["env"] != "dev" || (*labels)["team"] != "core" {
+		t.Fatalf("Got labels %v", *labels)
+	}
+	if (*firsts)["a"] != "1" {
+		t.Fatalf("Got firsts %v", *firsts)
+	}
+	if len(conf.Limits) != 2 || conf.Limits["cpu"] != 2 || conf.Limits["mem"] != 512 {
+		t.Fatalf("Got limits %v", conf.Limits)
+	}
+
+	Reset()
+	conf = Config{}
+	expect := "arg<-m/--limit> duplicate key <cpu>"
+	if err := ParseStruct([]string{"-m", "cpu=1", "-m", "cpu=2"}, &conf); err == nil || err.Error() != expect {
+		t.Fatalf("Expect <%s>, got <%v>", expect, err)
+	}
+	Reset()
+	conf = Config{}
+	expect = "arg<-m/--limit> key <cpu>: require int, got <x>"
+	if err := ParseStruct([]string{"-m", "cpu=x"}, &conf); err == nil || err.Error() != expect {
+		t.Fatalf("Expect <%s>, got <%v>", expect, err)
+	}
+}
+
+type upperValue string
+
+func (u *upperValue) String() string {
+	return string(*u)
+}
+
+func (u *upperValue) Set(s string) error {
+	if s == "" {
+		return fmt.Errorf("empty value")
+	}
+	*u = upperValue(strings.ToUpper(s))
+	return nil
+}
+
+func TestTypedArray(t *testing.T) {
+	Reset()
+	type Config struct {
+		Ports    []int           `golf:"short:p;long:ports;sep:','"`
+		Ratios   []float64       `golf:"long:ratio"`
+		Flags    []bool          `golf:"long:flag;sep:'|'"`
+		Timeouts []time.Duration `golf:"long:timeout;sep:',';max:3"`
+		Names    []upperValue    `golf:"long:name;sep:','"`
+		Level    upperValue      `golf:"long:level"`
+		Wait     time.Duration   `golf:"long:wait"`
+	}
+	var conf Config
+	hosts := Array("", "host", "", "", Separator(","), Items(1, 0))
+	args := []string{
+		"--ports", "80,443", "-p=8080", "--ratio", "0.5", "--ratio", "1.5", "--flag", "yes|0",
+		"--timeout", "1s,2m", "--name", "a,b", "--level", "debug", "--wait", "1h", "--host", "a.io,b.io",
+	}
+	if err := ParseStruct(args, &conf); err != nil {
+		t.Fatal(err)
+	}
+	expect := Config{
+		Ports:    []int{80, 443, 8080},
+		Ratios:   []float64{0.5, 1.5},
+		Flags:    []bool{true, false},
+		Timeouts: []time.Duration{time.Second, 2 * time.Minute},
+		Names:    []upperValue{"A", "B"},
+		Level:    "DEBUG",
+		Wait:     time.Hour,
+	}
+	if fmt.Sprint(conf) != fmt.Sprint(expect) {
+		t.Fatalf("Got %v, want %v", conf, expect)
+	}
+	if !arrayEqual(*hosts, []string{"a.io", "b.io"}) {
+		t.Fatalf("Got %v", *hosts)
+	}
+
+	Reset()
+	conf = Config{}
+	expectErr := "arg<--timeout> accepts at most 3 items, got 4"
+	if err := ParseStruct([]string{"--timeout", "1s,2s,3s,4s"}, &conf); err == nil || err.Error() != expectErr {
+		t.Fatalf("Expect <%s>, got <%v>", expectErr, err)
+	}
+	Reset()
+	conf = Config{}
+	expectErr = "arg<-p/--ports> require int, got <x>"
+	if err := ParseStruct([]string{"--ports", "80,x"}, &conf); err == nil || err.Error() != expectErr {
+		t.Fatalf("Expect <%s>, got <%v>", expectErr, err)
+	}
+	Reset()
+	_ = Array("", "host", "", "", Items(1, 0))
+	expectErr = "arg<--host> requires at least 1 items, got 0"
+	if err := Parse([]string{}); err == nil || err.Error() != expectErr {
+		t.Fatalf("Expect <%s>, got <%v>", expectErr, err)
+	}
+	Reset()
+	var level upperValue
+	Var(&level, "l", "level", "", "")
+	waits := DurationArray("w", "wait", "", "", Separator(","))
+	if err := Parse([]string{"-l", "warn", "-w", "1s,1ms"}); err != nil {
+		t.Fatal(err)
+	} else if level != "WARN" || len(*waits) != 2 || (*waits)[1] != time.Millisecond {
+		t.Fatalf("Got %v, %v", level, *waits)
+	}
+}
+
+func TestNestedStruct(t *testing.T) {
+	Reset()
+	type DBConfig struct {
+		Host string `golf:"long:host;default:'localhost'"`
+		Port int    `golf:"long:port"`
+	}
+	type CacheConfig struct {
+		Size int `golf:"long:size"`
+	}
+	type Common struct {
+		Verbose bool `golf:"short:v;long:verbose"`
+	}
+	type ServerConfig struct {
+		Common
+		DB    DBConfig     `golf:""`
+		Cache CacheConfig  `golf:"prefix:'cache-'"`
+		Log   *CacheConfig `golf:"prefix:'log-'"`
+		Extra *DBConfig    `golf:""`
+		Skip  DBConfig
+	}
+	var conf ServerConfig
+	args := []string{
+		"-v", "--db.host", "db.local", "--db.port=5432", "--cache-size", "64", "--log-size", "8",
+	}
+	if err := ParseStruct(args, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if !conf.Verbose || conf.DB.Host != "db.local" || conf.DB.Port != 5432 || conf.Cache.Size != 64 {
+		t.Fatalf("Got %+v", conf)
+	}
+	if conf.Log == nil || conf.Log.Size != 8 {
+		t.Fatalf("Expect Log allocated with size 8, got %+v", conf.Log)
+	}
+	if conf.Extra != nil {
+		t.Fatalf("Expect Extra to stay nil, got %+v", conf.Extra)
+	}
+
+	Reset()
+	type BadConfig struct {
+		DB DBConfig `golf:"long:db"`
+	}
+	expect := "golf parse tag of [DB] failed: invalid tag option <long> for struct"
+	if err := ParseStruct([]string{}, &BadConfig{}); err == nil || err.Error() != expect {
+		t.Fatalf("Expect <%s>, got <%v>", expect, err)
+	}
+}
+
+type listNode struct {
+	Name string    `golf:"l:name"`
+	Next *listNode `golf:""`
+}
+
+type treeNode struct {
+	Name     string `golf:"l:name"`
+	Children []*treeNode
+	Parent   *treeNode
+}
+
+func TestRecursiveStruct(t *testing.T) {
+	Reset()
+	var tree treeNode
+	if err := ParseStruct([]string{"--name", "root"}, &tree); err != nil || tree.Name != "root" || tree.Parent != nil {
+		t.Fatalf("Expect untagged fields to be skipped, got %v, %+v", err, tree)
+	}
+
+	Reset()
+	expect := "golf struct golf.listNode nests itself at [Next]"
+	if err := ParseStruct([]string{}, &listNode{}); err == nil || err.Error() != expect {
+		t.Fatalf("Expect <%s>, got <%v>", expect, err)
+	}
+}
+
+func TestStructDefault(t *testing.T) {
+	Reset()
+	type Config struct {
+		Host    string            `golf:"long:host;default:'localhost'"`
+		Port    int               `golf:"long:port;default:8080"`
+		Wait    time.Duration     `golf:"long:wait;default:5s"`
+		Tags    []string          `golf:"long:tag;sep:',';default:'a,b'"`
+		Labels  map[string]string `golf:"long:label;default:'env=dev'"`
+		Level   upperValue        `golf:"long:level;default:info"`
+		Verbose int               `golf:"short:v;count;default:1"`
+		Preset  string            `golf:"long:preset"`
+	}
+	conf := Config{
+		Preset: "kept",
+	}
+	if err := ParseStruct([]string{"--tag", "c", "-v"}, &conf); err != nil {
+		t.Fatal(err)
+	}
+	expect := Config{
+		Host:    "localhost",
+		Port:    8080,
+		Wait:    5 * time.Second,
+		Tags:    []string{"c"},
+		Labels:  map[string]string{"env": "dev"},
+		Level:   "INFO",
+		Verbose: 2,
+		Preset:  "kept",
+	}
+	if fmt.Sprint(conf) != fmt.Sprint(expect) {
+		t.Fatalf("Got %v, want %v", conf, expect)
+	}
+	usage := Usage("./test_exec")
+	for arg, def := range map[string]string{
+		"--host string":   "localhost",
+		"--wait duration": "5s",
+		"--tag array":     "[a b]",
+		"--level value":   "INFO",
+		"--preset string": "kept",
+	} {
+		line := fmt.Sprintf("  %-20s:  (default: \"%s\")", arg, def)
+		if !strings.Contains(usage, line) {
+			t.Fatalf("Expect usage to contain <%s>, got <%s>", line, usage)
+		}
+	}
+
+	Reset()
+	type BadConfig struct {
+		Port int `golf:"long:port;default:abc"`
+	}
+	expectErr := "golf parse tag of [Port] failed: invalid <default> val: abc"
+	if err := ParseStruct([]string{}, &BadConfig{}); err == nil || err.Error() != expectErr {
+		t.Fatalf("Expect <%s>, got <%v>", expectErr, err)
+	}
+}
+
+func TestOptionalPointer(t *testing.T) {
+	Reset()
+	type Config struct {
+		Retries *int           `golf:"long:retries"`
+		Name    *string        `golf:"long:name"`
+		Debug   *bool          `golf:"short:d;long:debug"`
+		Wait    *time.Duration `golf:"long:wait;default:1s"`
+		Level   *upperValue    `golf:"long:level"`
+	}
+	var conf Config
+	port := Int("p", "port", "", "", 80)
+	if err := ParseStruct([]string{"--retries", "0", "-d", "--level", "warn"}, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Retries == nil || *conf.Retries != 0 {
+		t.Fatalf("Expect retries 0, got %v", conf.Retries)
+	}
+	if conf.Name != nil {
+		t.Fatalf("Expect name nil, got %v", *conf.Name)
+	}
+	if conf.Debug == nil || !*conf.Debug {
+		t.Fatalf("Expect debug true, got %v", conf.Debug)
+	}
+	if conf.Wait == nil || *conf.Wait != time.Second {
+		t.Fatalf("Expect wait 1s, got %v", conf.Wait)
+	}
+	if conf.Level == nil || *conf.Level != "WARN" {
+		t.Fatalf("Expect level WARN, got %v", conf.Level)
+	}
+	for name, expect := range map[string]bool{
+		"retries": true, "--retries": true, "d": true, "-d": true, "name": false,
+		"wait": false, "port": false, "p": false, "unknown": false,
+	} {
+		if IsSet(name) != expect || Changed(name) != expect {
+			t.Fatalf("IsSet(%s) expect %v", name, expect)
+		}
+	}
+	if *port != 80 {
+		t.Fatalf("Expect port 80, got %d", *port)
+	}
+}
+
+func TestStructPositional(t *testing.T) {
+	Reset()
+	type Config struct {
+		Verbose bool          `golf:"short:v"`
+		Dst     string        `golf:"pos:2;name:dst"`
+		Srcs    []string      `golf:"pos:1;nargs:'+'"`
+		Mode    upperValue    `golf:"pos:0"`
+		Wait    time.Duration `golf:"pos:3;nargs:'?';default:1s"`
+		Rest    []int         `golf:"pos:rest"`
+	}
+	var conf Config
+	args := []string{
+		"copy", "a", "-v", "yes", "b", "c", "/tmp",
+	}
+	if err := ParseStruct(args, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Mode != "COPY" || !arrayEqual(conf.Srcs, []string{"a", "b", "c"}) || conf.Dst != "/tmp" ||
+		conf.Wait != time.Second || len(conf.Rest) != 0 || !conf.Verbose {
+		t.Fatalf("Got %+v", conf)
+	}
+
+	Reset()
+	type RestConfig struct {
+		Rest []int         `golf:"pos:rest"`
+		Wait time.Duration `golf:"pos:1;nargs:'?';default:1s"`
+		Src  string        `golf:"pos:0"`
+	}
+	var restConf RestConfig
+	if err := ParseStruct([]string{"a", "5s", "1", "2"}, &restConf); err != nil {
+		t.Fatal(err)
+	}
+	if restConf.Src != "a" || restConf.Wait != 5*time.Second || fmt.Sprint(restConf.Rest) != "[1 2]" {
+		t.Fatalf("Got %+v", restConf)
+	}
+	Reset()
+	expect := "arg<wait> require duration, got <x>"
+	if err := ParseStruct([]string{"a", "x"}, &RestConfig{}); err == nil || err.Error() != expect {
+		t.Fatalf("Expect <%s>, got <%v>", expect, err)
+	}
+
+	Reset()
+	expect = "missing argument: dst"
+	if err := ParseStruct([]string{"copy", "a"}, &Config{}); err == nil || err.Error() != expect {
+		t.Fatalf("Expect <%s>, got <%v>", expect, err)
+	}
+
+	Reset()
+	type BadConfig struct {
+		Files string `golf:"pos:0;nargs:'*'"`
+	}
+	expect = "golf parse tag of [Files] failed: positional with more than one value requires a slice"
+	if err := ParseStruct([]string{}, &BadConfig{}); err == nil || err.Error() != expect {
+		t.Fatalf("Expect <%s>, got <%v>", expect, err)
+	}
+
+	Reset()
+	type DupConfig struct {
+		Src string `golf:"pos:0"`
+		Dst string `golf:"pos:0"`
+	}
+	expect = "golf parse tag of [Dst] failed: duplicate position 0: field [Src] and field [Dst]"
+	if err := ParseStruct([]string{}, &DupConfig{}); err == nil || err.Error() != expect {
+		t.Fatalf("Expect <%s>, got <%v>", expect, err)
+	}
+
+	Reset()
+	BareString("mode", "")
+	type ModeConfig struct {
+		Mode string `golf:"pos:0"`
+	}
+	expect = "golf parse tag of [Mode] failed: duplicate position 0: arg<mode> at golf_test.go:N and field [Mode]"
+	if err := ParseStruct([]string{}, &ModeConfig{}); err == nil || stripLines(err.Error()) != expect {
+		t.Fatalf("Expect <%s>, got <%v>", expect, err)
+	}
+}
+
+func TestBareTyped(t *testing.T) {
+	Reset()
+	srcs := BareArray("src", "Sources", Items(1, 3))
+	dst := BareString("dst", "Destination")
+	count := BareInt("count", "Copies", Optional("1"))
+	ratio := BareFloat("ratio", "Ratio", Optional(""))
+	if err := Parse([]string{"a", "b", "/tmp"}); err != nil {
+		t.Fatal(err)
+	}
+	if !arrayEqual(*srcs, []string{"a", "b"}) || *dst != "/tmp" || *count != 1 || *ratio != 0 {
+		t.Fatalf("Got %v, %s, %d, %v", *srcs, *dst, *count, *ratio)
+	}
+	expect := `Usage:
+  ./test_exec <src>... <dst> [count] [ratio]
+
+Arguments:
+  src                 : Sources (default: "[]")
+  dst                 : Destination (required)
+  count               : Copies (default: "1")
+  ratio               : Ratio (default: "0")
+`
+	if str := Usage("./test_exec"); str != expect {
+		t.Fatalf("Expect usage <%s>, got <%s>", expect, str)
+	}
+
+	for expect, args := range map[string][]string{
+		"missing argument: dst":           {"a"},
+		"too many arguments: x y":         {"a", "b", "c", "/tmp", "2", "0.5", "x", "y"},
+		"arg<count> require int, got <x>": {"a", "b", "c", "/tmp", "x"},
+	} {
+		Reset()
+		_ = BareArray("src", "Sources", Items(1, 3))
+		_ = BareString("dst", "Destination")
+		_ = BareInt("count", "Copies", Optional("1"))
+		_ = BareFloat("ratio", "Ratio", Optional(""))
+		if err := Parse(args); err == nil || err.Error() != expect {
+			t.Fatalf("Expect <%s>, got <%v>", expect, err)
+		}
+	}
+}
+
+func TestTagFallback(t *testing.T) {
+	type DBConfig struct {
+		Host string `json:"host" usage:"Database host"`
+	}
+	type Config struct {
+		ListenAddr string   `json:"listen_addr,omitempty" help:"Address to listen on"`
+		Timeout    int      `yaml:"timeout" json:",omitempty"`
+		Ignored    string   `json:"-"`
+		Tags       []string `flag:"tag"`
+		Port       int      `golf:"short:p"`
+		Database   DBConfig `json:"db"`
+	}
+
+	Reset()
+	var conf Config
+	args := []string{
+		"--listen_addr", ":80", "--timeout", "5", "--tag", "a", "-p", "8080", "--db.host", "h",
+	}
+	if err := ParseStruct(args, &conf); err == nil {
+		t.Fatalf("Expect error without fallback, got %+v", conf)
+	}
+
+	Reset()
+	SetTagFallback("json")
+	conf = Config{}
+	if err := ParseStruct(args, &conf); err != nil {
+		t.Fatal(err)
+	}
+	expect := Config{
+		ListenAddr: ":80",
+		Timeout:    5,
+		Tags:       []string{"a"},
+		Port:       8080,
+		Database:   DBConfig{Host: "h"},
+	}
+	if fmt.Sprint(conf) != fmt.Sprint(expect) {
+		t.Fatalf("Got %+v, want %+v", conf, expect)
+	}
+	if _, ok := g.longs["ignored"]; ok {
+		t.Fatalf("Expect field tagged json:\"-\" to be skipped")
+	}
+	usage := Usage("./test_exec")
+	for _, help := range []string{"Address to listen on", "Database host"} {
+		if !strings.Contains(usage, help) {
+			t.Fatalf("Expect usage to contain <%s>, got <%s>", help, usage)
+		}
+	}
+
+	Reset()
+	SetTagFallback("yaml", "json")
+	conf = Config{}
+	if err := ParseStruct([]string{"--timeout", "3"}, &conf); err != nil {
+		t.Fatal(err)
+	} else if conf.Timeout != 3 {
+		t.Fatalf("Expect timeout 3, got %d", conf.Timeout)
+	}
+}
+
+func TestTagFallbackUnsupported(t *testing.T) {
+	type Config struct {
+		Limit uint16         `json:"limit"`
+		Done  chan bool      `json:"done"`
+		Index map[int]string `json:"index"`
+		Run   func()         `json:"run"`
+	}
+	Reset()
+	SetTagFallback("json")
+	var conf Config
+	if err := ParseStruct([]string{"--limit", "7"}, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Limit != 7 {
+		t.Fatalf("Expect limit 7, got %d", conf.Limit)
+	}
+	for _, long := range []string{"done", "index", "run"} {
+		if _, ok := g.longs[long]; ok {
+			t.Fatalf("Expect unsupported field <%s> to be skipped", long)
+		}
+	}
+	for value, expect := range map[string]string{
+		"-1":    "arg<--limit> require uint, got <-1>",
+		"70000": "arg<--limit> require uint, got <70000>",
+	} {
+		if err := Parse([]string{"--limit", value}); err == nil || err.Error() != expect {
+			t.Fatalf("Expect <%s>, got <%v>", expect, err)
+		}
+	}
+}
+
+var lineNumber = regexp.MustCompile(`\.go:\d+`)
+
+// stripLines drops the line numbers of call sites in error messages.
+func stripLines(msg string) string {
+	return lineNumber.ReplaceAllString(msg, ".go:N")
+}
+
+func expectPanic(t *testing.T, expect string, f func()) {
+	defer func() {
+		r := recover()
+		if err, ok := r.(error); !ok || stripLines(err.Error()) != expect {
+			t.Fatalf("Expect panic <%s>, got <%v>", expect, r)
+		}
+	}()
+	f()
+}
+
+func TestDuplicateName(t *testing.T) {
+	Reset()
+	_ = String("o", "output", "", "", "", Alias("out"))
+	expectPanic(t, "duplicate name -o: arg<-o/--output> at golf_test.go:N and arg<-o> at golf_test.go:N", func() {
+		_ = Bool("o", "", "", "", false)
+	})
+	expectPanic(t, "duplicate name --out: arg<-o/--output> at golf_test.go:N and arg<--verbose> at golf_test.go:N", func() {
+		_ = Count("", "verbose", "", "", Alias("--out"))
+	})
+	expectPanic(t, "invalid name <--bad=name> of arg<--bad=name> at golf_test.go:N; invalid name <--> of arg<--bad=name> at golf_test.go:N", func() {
+		_ = Int("", "bad=name", "", "", 0, Alias("--"))
+	})
+
+	type Config struct {
+		Output string `golf:"long:output"`
+	}
+	expect := "golf parse tag of [Output] failed: duplicate name --output: arg<-o/--output> at golf_test.go:N and field [Output]"
+	if err := ParseStruct([]string{}, &Config{}); err == nil || stripLines(err.Error()) != expect {
+		t.Fatalf("Expect <%s>, got <%v>", expect, err)
+	}
+
+	Reset()
+	host := String("h", "host", "", "", "")
+	os.Args = []string{"./test_exec", "-h", "a.io", "--help"}
+	if help, err := ParseOSArgs(); err != nil {
+		t.Fatal(err)
+	} else if !help || *host != "a.io" {
+		t.Fatalf("Expect help and host a.io, got %v, %s", help, *host)
+	}
+}
+
+func TestSecret(t *testing.T) {
+	Reset()
+	_ = String("", "token", "", "API Token", "t0ps3cret", Secret())
+	_ = Int("", "pin", "", "PIN", 0, Secret())
+	if usage := Usage("app"); strings.Contains(usage, "t0ps3cret") || !strings.Contains(usage, `API Token (default: "******")`) {
+		t.Fatalf("Expect masked default, got %s", usage)
+	}
+	if err := Parse([]string{"--pin", "12ab"}); err == nil || err.Error() != "arg<--pin> require int, got <******>" {
+		t.Fatalf("Expect masked error, got %v", err)
+	}
+
+	Reset()
+	var conf struct {
+		Keys []int          `golf:"l:keys;secret"`
+		Vars map[string]int `golf:"l:vars;secret"`
+		Name string         `golf:"l:name"`
+		Pass string         `golf:"l:pass;secret;default:hunter2"`
+	}
+	if err := ParseStruct([]string{"--keys", "9x9"}, &conf); err == nil || strings.Contains(err.Error(), "9x9") {
+		t.Fatalf("Expect masked item, got %v", err)
+	}
+	Reset()
+	if err := ParseStruct([]string{"--vars", "a=b4d"}, &conf); err == nil || strings.Contains(err.Error(), "b4d") {
+		t.Fatalf("Expect masked map value, got %v", err)
+	}
+	Reset()
+	if err := ParseStruct([]string{"--pass", "p4ss"}, &conf); err != nil || conf.Pass != "p4ss" {
+		t.Fatalf("Expect secret to parse, got %v, %s", err, conf.Pass)
+	}
+}
@@ -0,0 +1,114 @@
+package golf
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NameFunc maps a Go field name such as "ListenAddr" to the name of an
+// option, an environment variable or a config key.
+type NameFunc func(field string) string
+
+// Naming decides how ParseStruct derives names from fields. Long is used
+// for empty golf tags and nested struct prefixes, Env for environment
+// variables and Key for config file keys; a nil Key keeps the long names.
+type Naming struct {
+	Long NameFunc
+	Env  NameFunc
+	Key  NameFunc
+}
+
+func defaultNaming() Naming {
+	return Naming{
+		Long: SnakeCase,
+		Env:  UpperSnakeCase,
+		Key:  nil,
+	}
+}
+
+func SnakeCase(field string) string {
+	snake := nameFirstCap.ReplaceAllString(field, "${1}_${2}")
+	return strings.ToLower(nameAllCap.ReplaceAllString(snake, "${1}_${2}"))
+}
+
+func KebabCase(field string) string {
+	return strings.ReplaceAll(SnakeCase(field), "_", "-")
+}
+
+func UpperSnakeCase(field string) string {
+	return strings.ToUpper(SnakeCase(field))
+}
+
+func CamelCase(field string) string {
+	words := strings.Split(SnakeCase(field), "_")
+	for i := 1; i < len(words); i++ {
+		if words[i] != "" {
+			words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// SetNaming replaces the naming strategy, nil functions keep the defaults.
+func SetNaming(naming Naming) {
+	def := defaultNaming()
+	if naming.Long == nil {
+		naming.Long = def.Long
+	}
+	if naming.Env == nil {
+		naming.Env = def.Env
+	}
+	g.naming = naming
+}
+
+// SetAutoShort makes ParseStruct give every field without a short name the
+// first letter of its long name that is still free.
+func SetAutoShort(enable bool) {
+	g.autoShort = enable
+}
+
+func (g *golf) assignShorts(opts []*golfOpt) {
+	for _, opt := range opts {
+		if opt.Bare || opt.Short != "" || opt.Long == "" {
+			continue
+		}
+		for _, c := range shortCandidates(opt.Long) {
+			if _, taken := g.shorts[c]; !taken {
+				opt.Short = c
+				g.shorts[c] = opt
+				break
+			}
+		}
+	}
+}
+
+// shortCandidates lists the letters of a long name: initials of its words
+// before the other letters, lower case before upper case.
+func shortCandidates(long string) []string {
+	initials := make([]rune, 0)
+	others := make([]rune, 0)
+	wordStart := true
+	for _, c := range long {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			wordStart = true
+			continue
+		}
+		if wordStart {
+			initials = append(initials, c)
+		} else {
+			others = append(others, c)
+		}
+		wordStart = false
+	}
+	candidates := make([]string, 0, 2*len(long))
+	for _, letters := range [][]rune{initials, others} {
+		for _, convert := range []func(rune) rune{unicode.ToLower, unicode.ToUpper} {
+			for _, c := range letters {
+				if cc := string(convert(c)); !existInArray(candidates, cc) {
+					candidates = append(candidates, cc)
+				}
+			}
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,65 @@
+package golf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNamingFuncs(t *testing.T) {
+	for field, expect := range map[string][]string{
+		"ListenAddr": {"listen_addr", "listen-addr", "listenAddr", "LISTEN_ADDR"},
+		"HTTPPort":   {"http_port", "http-port", "httpPort", "HTTP_PORT"},
+		"ID":         {"id", "id", "id", "ID"},
+	} {
+		got := []string{SnakeCase(field), KebabCase(field), CamelCase(field), UpperSnakeCase(field)}
+		if strings.Join(got, " ") != strings.Join(expect, " ") {
+			t.Fatalf("%s: expect %v, got %v", field, expect, got)
+		}
+	}
+}
+
+func TestNaming(t *testing.T) {
+	Reset()
+	SetNaming(Naming{
+		Long: KebabCase,
+		Key:  CamelCase,
+	})
+	SetAutoShort(true)
+	type DBConfig struct {
+		MaxConns int `golf:""`
+	}
+	type Config struct {
+		ListenAddr string   `golf:""`
+		LogLevel   string   `golf:""`
+		Limit      int      `golf:"short:l;long:limit"`
+		Database   DBConfig `golf:""`
+		Custom     string   `golf:"long:custom"`
+	}
+	var conf Config
+	args := []string{
+		"--listen-addr", ":80", "-L", "debug", "-l", "5", "--database.max-conns", "10", "-c", "x",
+	}
+	if err := ParseStruct(args, &conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.ListenAddr != ":80" || conf.LogLevel != "debug" || conf.Limit != 5 || conf.Database.MaxConns != 10 ||
+		conf.Custom != "x" {
+		t.Fatalf("Got %+v", conf)
+	}
+	expect := map[string][3]string{
+		"listen-addr":        {"a", "LISTEN_ADDR", "listenAddr"},
+		"log-level":          {"L", "LOG_LEVEL", "logLevel"},
+		"limit":              {"l", "LIMIT", "limit"},
+		"database.max-conns": {"d", "DATABASE_MAX_CONNS", "database.maxConns"},
+		"custom":             {"c", "CUSTOM", "custom"},
+	}
+	for long, names := range expect {
+		opt := g.longs[long]
+		if opt == nil {
+			t.Fatalf("Expect option --%s", long)
+		}
+		if got := [3]string{opt.Short, opt.Env, opt.Key}; got != names {
+			t.Fatalf("--%s: expect %v, got %v", long, names, got)
+		}
+	}
+}
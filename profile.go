@@ -0,0 +1,77 @@
+package golf
+
+import (
+	"fmt"
+	"os"
+)
+
+// SetProfile selects the config profile used when neither the option nor
+// the environment variable given to ProfileFlag name one.
+func SetProfile(name string) {
+	g.profile = name
+}
+
+// ProfileFlag registers the option and the environment variable selecting
+// the config profile, e.g. ProfileFlag("profile", "APP_PROFILE"). The
+// option wins over the variable, which wins over SetProfile.
+func ProfileFlag(long, env string) *string {
+	profile := String("", long, "name", "Config profile", "")
+	g.profileOpt = g.all[len(g.all)-1]
+	g.profileOpt.reserved = true
+	g.profileEnv = env
+	return profile
+}
+
+// ActiveProfile returns the profile applied by the last Parse and where it
+// was selected.
+func ActiveProfile() (string, string) {
+	return g.activeProfile, g.profileFrom
+}
+
+func (g *golf) selectProfile() (string, error) {
+	name, from := g.profile, "SetProfile"
+	if value, ok := os.LookupEnv(g.profileEnv); ok && g.profileEnv != "" && value != "" {
+		name, from = value, "env "+g.profileEnv
+	}
+	if opt := g.profileOpt; opt != nil && opt.IsSet {
+		name, from = fmt.Sprint(opt.currentValue()), opt.Source.String()
+	}
+	g.activeProfile, g.profileFrom = "", ""
+	if name == "" {
+		return "", nil
+	}
+	found := false
+	for _, conf := range g.configs {
+		for _, entry := range conf.entries {
+			found = found || entry.profile == name
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("config profile <%s> from %s not found", name, from)
+	}
+	g.activeProfile, g.profileFrom = name, from
+	return name, nil
+}
+
+// merged returns the base entries with the keys of the profile replaced by
+// its own entries.
+func (c configFile) merged(profile string) []configEntry {
+	overlay := map[string]bool{}
+	for _, entry := range c.entries {
+		if profile != "" && entry.profile == profile {
+			overlay[entry.key] = true
+		}
+	}
+	entries := make([]configEntry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		if entry.profile == "" && !overlay[entry.key] {
+			entries = append(entries, entry)
+		}
+	}
+	for _, entry := range c.entries {
+		if profile != "" && entry.profile == profile {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,79 @@
+package golf
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const profileConf = `host = localhost
+port = 80
+tag = base
+
+[profile:prod]
+host = prod.example.com
+tag = p1
+tag = p2
+
+[profile:prod db]
+user = admin
+
+[db]
+user = dev
+`
+
+func TestProfile(t *testing.T) {
+	conf := writeFile(t, t.TempDir(), "app.conf", profileConf)
+	setup := func() (*string, *int, *[]string, *string) {
+		Reset()
+		ProfileFlag("profile", "APP_PROFILE")
+		host := String("", "host", "", "", "")
+		port := Int("", "port", "", "", 0)
+		tags := Array("", "tag", "", "")
+		user := String("", "db.user", "", "", "")
+		if err := LoadConfig(conf); err != nil {
+			t.Fatal(err)
+		}
+		return host, port, tags, user
+	}
+
+	host, port, tags, user := setup()
+	if err := Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if *host != "localhost" || !arrayEqual(*tags, []string{"base"}) || *user != "dev" {
+		t.Fatalf("Got %s, %q, %s", *host, *tags, *user)
+	}
+
+	host, port, tags, user = setup()
+	if err := Parse([]string{"--profile", "prod", "--host", "cli"}); err != nil {
+		t.Fatal(err)
+	}
+	if *host != "cli" || *port != 80 || !arrayEqual(*tags, []string{"p1", "p2"}) || *user != "admin" {
+		t.Fatalf("Got %s, %d, %q, %s", *host, *port, *tags, *user)
+	}
+	if name, from := ActiveProfile(); name != "prod" || from != "arg #0" {
+		t.Fatalf("Got %s from %s", name, from)
+	}
+	if src, _ := SourceOf("db.user"); src.String() != "file "+conf+":11 [profile prod]" {
+		t.Fatalf("Got %s", src)
+	}
+	if !strings.HasPrefix(Explain(), "config: "+conf+"\nprofile: prod (arg #0)\n") {
+		t.Fatalf("Got %s", Explain())
+	}
+
+	os.Setenv("APP_PROFILE", "prod")
+	defer os.Unsetenv("APP_PROFILE")
+	host, _, _, _ = setup()
+	if err := Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if name, from := ActiveProfile(); *host != "prod.example.com" || name != "prod" || from != "env APP_PROFILE" {
+		t.Fatalf("Got %s, %s from %s", *host, name, from)
+	}
+
+	setup()
+	if err := Parse([]string{"--profile=stage"}); err == nil || err.Error() != "config profile <stage> from arg #0 not found" {
+		t.Fatalf("Expect missing profile, got %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package golf
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type ResponseFormat int
+
+const (
+	// ResponseOff leaves "@file" arguments alone.
+	ResponseOff ResponseFormat = iota
+	// ResponseLines reads one argument per line.
+	ResponseLines
+	// ResponseShell splits every line into shell-quoted words, lines
+	// starting with '#' are comments.
+	ResponseShell
+)
+
+// SetResponseFiles makes Parse replace "@file" arguments by the arguments
+// read from file. Files may refer to further files nested up to maxDepth
+// levels, 0 allows "@file" arguments on the command line only.
+func SetResponseFiles(format ResponseFormat, maxDepth int) {
+	g.respFormat = format
+	g.respDepth = maxDepth
+}
+
+// argToken is an argument of Parse at index, chain holds the response files
+// it was read from, outermost first.
+type argToken struct {
+	val   string
+	index int
+	chain []respFrame
+}
+
+type respFrame struct {
+	path string
+	line int
+}
+
+func (t argToken) where() string {
+	if len(t.chain) == 0 {
+		return "command line"
+	}
+	last := t.chain[len(t.chain)-1]
+	return fmt.Sprintf("%s:%d", last.path, last.line)
+}
+
+func (t argToken) source() Source {
+	src := Source{Kind: SourceArgs, Index: t.index}
+	if len(t.chain) != 0 {
+		src.Name = t.where()
+	}
+	return src
+}
+
+func isResponseFile(entry string) bool {
+	return g.respFormat != ResponseOff && len(entry) > 1 && strings.HasPrefix(entry, "@")
+}
+
+func (g *golf) expandResponse(token argToken) ([]argToken, error) {
+	path := strings.TrimPrefix(token.val, "@")
+	if len(token.chain) > g.respDepth {
+		return nil, fmt.Errorf("response file %s: %s nested deeper than %d", token.where(), token.val, g.respDepth)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("response file %s: %v", token.where(), err)
+	}
+	for _, frame := range token.chain {
+		if frame.path == abs {
+			names := make([]string, 0, len(token.chain)+1)
+			for _, f := range token.chain {
+				names = append(names, filepath.Base(f.path))
+			}
+			names = append(names, filepath.Base(abs))
+			return nil, fmt.Errorf("response file %s: cycle %s", token.where(), strings.Join(names, " -> "))
+		}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("response file %s: %v", token.where(), err)
+	}
+	defer file.Close()
+
+	tokens := make([]argToken, 0)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		chain := append(append([]respFrame{}, token.chain...), respFrame{path: abs, line: line})
+		text := strings.TrimRight(scanner.Text(), "\r")
+		var words []string
+		if g.respFormat == ResponseLines {
+			if text == "" {
+				continue
+			}
+			words = []string{text}
+		} else if words, err = shellSplit(text); err != nil {
+			return nil, fmt.Errorf("response file %s:%d: %v", abs, line, err)
+		}
+		for _, word := range words {
+			tokens = append(tokens, argToken{val: word, index: token.index, chain: chain})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("response file %s: %v", abs, err)
+	}
+	return tokens, nil
+}
+
+// shellSplit splits a line into words like a POSIX shell without expansions:
+// single quotes are literal, double quotes and backslashes escape.
+func shellSplit(line string) ([]string, error) {
+	words := make([]string, 0)
+	word := strings.Builder{}
+	inWord := false
+	var quote rune
+	escaped := false
+	for i, c := range line {
+		switch {
+		case escaped:
+			word.WriteRune(c)
+			escaped = false
+		case quote == '\'':
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case quote == '"':
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && i+1 < len(line) && strings.ContainsRune("\"\\$`", rune(line[i+1])) {
+				escaped = true
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '\\':
+			escaped, inWord = true, true
+		case c == '\'' || c == '"':
+			quote, inWord = c, true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '#' && !inWord:
+			return words, nil
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
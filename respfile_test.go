@@ -0,0 +1,100 @@
+package golf
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestShellSplit(t *testing.T) {
+	for line, expect := range map[string][]string{
+		`-v a b`:                  {"-v", "a", "b"},
+		`  --name 'John Smith'  `: {"--name", "John Smith"},
+		`--msg "say \"hi\" \n"`:   {"--msg", `say "hi" \n`},
+		`a\ b c# d # comment`:     {"a b", "c#", "d"},
+		`''`:                      {""},
+		`# only a comment`:        {},
+	} {
+		words, err := shellSplit(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(words, "|") != strings.Join(expect, "|") || len(words) != len(expect) {
+			t.Fatalf("%s: expect %q, got %q", line, expect, words)
+		}
+	}
+	if _, err := shellSplit(`a 'b`); err == nil || err.Error() != "unterminated ' quote" {
+		t.Fatalf("Expect unterminated quote, got %v", err)
+	}
+}
+
+func TestResponseFile(t *testing.T) {
+	dir := t.TempDir()
+	nested := writeFile(t, dir, "nested.txt", "-v v3\n")
+	shell := writeFile(t, dir, "shell.txt", "# volumes\n-v v1 -v 'v 2'\n@"+nested+"\n")
+	lines := writeFile(t, dir, "lines.txt", "-v\nv 4\n")
+
+	Reset()
+	SetResponseFiles(ResponseShell, 1)
+	vols := Array("v", "volume", "", "")
+	name := String("n", "name", "", "", "")
+	if err := Parse([]string{"@" + shell, "-n", "@literal", "-v", "v5"}); err != nil {
+		t.Fatal(err)
+	}
+	if !arrayEqual(*vols, []string{"v1", "v 2", "v3", "v5"}) || *name != "@literal" {
+		t.Fatalf("Got %q, %s", *vols, *name)
+	}
+
+	Reset()
+	SetResponseFiles(ResponseLines, 0)
+	vols = Array("v", "volume", "", "")
+	if err := Parse([]string{"@" + lines}); err != nil {
+		t.Fatal(err)
+	} else if !arrayEqual(*vols, []string{"v 4"}) {
+		t.Fatalf("Got %q", *vols)
+	}
+
+	Reset()
+	vols = Array("v", "volume", "", "")
+	files := BareArray("files", "")
+	if err := Parse([]string{"@" + lines}); err != nil {
+		t.Fatal(err)
+	} else if !arrayEqual(*files, []string{"@" + lines}) {
+		t.Fatalf("Expect response files to be off by default, got %q", *files)
+	}
+}
+
+func TestResponseFileError(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := writeFile(t, dir, "b.txt", "-v b\n@"+a+"\n")
+	writeFile(t, dir, "a.txt", "-v a\n@"+b+"\n")
+	quote := writeFile(t, dir, "quote.txt", "-v ok\n-v 'broken\n")
+
+	for expect, arg := range map[string]string{
+		"response file " + b + ":2: cycle a.txt -> b.txt -> a.txt":                                           a,
+		"response file " + quote + ":2: unterminated ' quote":                                                quote,
+		"response file " + a + ":2: @" + b + " nested deeper than 0":                                         "",
+		"response file command line: open " + filepath.Join(dir, "none.txt") + ": no such file or directory": filepath.Join(dir, "none.txt"),
+	} {
+		Reset()
+		depth := 5
+		if arg == "" {
+			arg, depth = a, 0
+		}
+		SetResponseFiles(ResponseShell, depth)
+		_ = Array("v", "volume", "", "")
+		if err := Parse([]string{"@" + arg}); err == nil || err.Error() != expect {
+			t.Fatalf("Expect <%s>, got <%v>", expect, err)
+		}
+	}
+}
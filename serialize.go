@@ -0,0 +1,239 @@
+package golf
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ToArgs rebuilds the arguments for the current values: options which differ
+// from their default as "--long=value", then the positionals, after "--"
+// when one of them looks like an option or response file. Every entry is a
+// single argument, use ShellJoin to print them for a shell. Items which
+// Parse would split at the separator of their option are refused.
+func ToArgs() ([]string, error) {
+	args := make([]string, 0)
+	for _, opt := range g.all {
+		if opt.Bare || opt.reserved || !opt.changed() {
+			continue
+		}
+		name := "--" + opt.Long
+		if opt.Long == "" {
+			name = "-" + opt.Short
+		}
+		values := opt.argValues()
+		if err := opt.checkSplit(values); err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			args = append(args, name+"="+opt.escapeFileValue(value))
+		}
+	}
+	bares := make([]string, 0)
+	for _, opt := range g.positionals() {
+		if !opt.IsSet {
+			continue
+		}
+		values := opt.argValues()
+		if err := opt.checkSplit(values); err != nil {
+			return nil, err
+		}
+		bares = append(bares, values...)
+	}
+	for _, bare := range bares {
+		if strings.HasPrefix(bare, "-") || strings.HasPrefix(bare, "@") {
+			args = append(args, "--")
+			break
+		}
+	}
+	return append(args, bares...), nil
+}
+
+// checkSplit refuses items which Parse would split into several: array
+// items containing the separator of their option and map pairs with ','.
+func (o golfOpt) checkSplit(items []string) error {
+	sep := o.Sep
+	if o.Type == optMap {
+		sep = ","
+	} else if o.Type != optArray {
+		return nil
+	}
+	for _, item := range items {
+		if sep != "" && strings.Contains(item, sep) {
+			return fmt.Errorf("arg<%s> item <%s> contains separator <%s>", o.debugArg(), o.display(item), sep)
+		}
+	}
+	return nil
+}
+
+// escapeFileValue keeps values of FileValue options from being read as
+// "@path" or stdin.
+func (o golfOpt) escapeFileValue(value string) string {
+	if !o.FromFile {
+		return value
+	}
+	if value == "-" || strings.HasPrefix(value, "@") {
+		return "@" + value
+	}
+	return value
+}
+
+// ToEnv returns "NAME=value" pairs for the options which differ from their
+// default, as read by SetEnv with the same prefix. Items of arrays and maps
+// are joined by their separator or ','. Commas inside the items of arrays
+// without separator are escaped, other items containing their separator
+// cannot be read back and are refused.
+func ToEnv() ([]string, error) {
+	env := make([]string, 0)
+	for _, opt := range g.all {
+		if opt.Bare || opt.reserved || opt.Env == "" || !opt.changed() {
+			continue
+		}
+		items := opt.argValues()
+		sep := ","
+		if opt.Type == optArray && opt.Sep == "" {
+			for i, item := range items {
+				items[i] = envEscaper.Replace(item)
+			}
+		} else if err := opt.checkSplit(items); err != nil {
+			return nil, err
+		} else if opt.Sep != "" {
+			sep = opt.Sep
+		}
+		env = append(env, g.envPrefix+opt.Env+"="+strings.Join(items, sep))
+	}
+	return env, nil
+}
+
+var envEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`)
+
+// ToJSON returns every option by its config key, keys with dots become
+// nested objects.
+func ToJSON() ([]byte, error) {
+	doc := map[string]any{}
+	for _, opt := range g.all {
+		if opt.Bare || opt.reserved || opt.Key == "" {
+			continue
+		}
+		parts := strings.Split(opt.Key, ".")
+		node := doc
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				node[part] = child
+			}
+			node = child
+		}
+		node[parts[len(parts)-1]] = opt.jsonValue()
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// ShellJoin quotes args so that a shell, or a ResponseShell file, splits
+// them back into the same arguments.
+func ShellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("-_./=:,@%+", c) && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') {
+			return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+		}
+	}
+	return s
+}
+
+func (o golfOpt) changed() bool {
+	if o.Required {
+		return o.IsSet
+	}
+	return !reflect.DeepEqual(o.currentValue(), o.Default)
+}
+
+// argValues formats the current value as accepted by Parse, one entry per
+// occurrence of the option.
+func (o golfOpt) argValues() []string {
+	return o.formatValues(o.currentValue())
+}
+
+func (o golfOpt) formatValues(value any) []string {
+	if value == nil {
+		return nil
+	}
+	if o.Type == optValue {
+		return []string{fmt.Sprint(value)}
+	}
+	v := reflect.ValueOf(value)
+	switch o.Type {
+	case optArray:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatValue(v.Index(i))
+		}
+		return items
+	case optMap:
+		keys := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, key := range keys {
+			pairs[i] = key + "=" + formatValue(v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key())))
+		}
+		return pairs
+	}
+	return []string{formatValue(v)}
+}
+
+func (o golfOpt) jsonValue() any {
+	switch o.Type {
+	case optValue, optDuration:
+		if value := o.argValues(); len(value) != 0 {
+			return value[0]
+		}
+		return nil
+	case optArray:
+		if o.hasCustomElem() {
+			return append([]string{}, o.argValues()...)
+		}
+	}
+	value := o.currentValue()
+	if v := reflect.ValueOf(value); value != nil && (o.Type == optArray || o.Type == optMap) && v.IsNil() {
+		// nil slices and maps are written as empty ones, not null
+		if o.Type == optArray {
+			return reflect.MakeSlice(v.Type(), 0, 0).Interface()
+		}
+		return reflect.MakeMap(v.Type()).Interface()
+	}
+	return value
+}
+
+func (o golfOpt) hasCustomElem() bool {
+	elem := o.ResultSetter.Type().Elem()
+	return elem == durationType || reflect.PtrTo(elem).Implements(valueType)
+}
+
+func formatValue(v reflect.Value) string {
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	if custom, ok := ptr.Interface().(Value); ok {
+		return custom.String()
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	return fmt.Sprint(v.Interface())
+}
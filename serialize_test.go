@@ -0,0 +1,246 @@
+package golf
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type serialConf struct {
+	Name    string            `golf:"s:n;l:name;required"`
+	Port    int               `golf:"l:port;default:80"`
+	Debug   bool              `golf:"l:debug"`
+	Verbose int               `golf:"s:v;l:verbose;count"`
+	Tags    []string          `golf:"l:tag"`
+	Waits   []time.Duration   `golf:"l:wait"`
+	Labels  map[string]string `golf:"l:label"`
+	Upper   upperValue        `golf:"l:upper"`
+	Server  struct {
+		Host string `golf:""`
+	} `golf:""`
+	Files []string `golf:"pos:0;nargs:*"`
+}
+
+func TestToArgs(t *testing.T) {
+	Reset()
+	var conf serialConf
+	args := []string{"-n", "it's me", "--debug", "-vv", "--tag", "a", "--tag", "-b", "--wait", "1m",
+		"--label", "x=1,y=2", "--upper", "up", "--server.host", "h", "f1", "f 2"}
+	if err := ParseStruct(args, &conf); err != nil {
+		t.Fatal(err)
+	}
+	rebuilt, err := ToArgs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"--name=it's me", "--debug=true", "--verbose=2", "--tag=a", "--tag=-b", "--wait=1m0s",
+		"--label=x=1", "--label=y=2", "--upper=UP", "--server.host=h", "f1", "f 2"}
+	if !arrayEqual(rebuilt, expect) {
+		t.Fatalf("Expect %q, got %q", expect, rebuilt)
+	}
+	if line := ShellJoin(rebuilt[:2]); line != `'--name=it'\''s me' --debug=true` {
+		t.Fatalf("Got %s", line)
+	}
+
+	Reset()
+	var again serialConf
+	if err := ParseStruct(rebuilt, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(conf, again) {
+		t.Fatalf("Expect %+v, got %+v", conf, again)
+	}
+}
+
+func TestToEnv(t *testing.T) {
+	Reset()
+	SetEnv(true, "APP_")
+	var conf serialConf
+	if err := ParseStruct([]string{"-n", "me", "--tag", "a", "--tag", "b", "--port", "81"}, &conf); err != nil {
+		t.Fatal(err)
+	}
+	env, err := ToEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"APP_NAME=me", "APP_PORT=81", "APP_TAGS=a,b"}
+	if !arrayEqual(env, expect) {
+		t.Fatalf("Expect %q, got %q", expect, env)
+	}
+
+	for _, kv := range env {
+		k, v := kv[:len("APP_....")], kv[len("APP_....")+1:]
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+	Reset()
+	SetEnv(true, "APP_")
+	var again serialConf
+	if err := ParseStruct([]string{}, &again); err != nil {
+		t.Fatal(err)
+	}
+	if again.Name != "me" || again.Port != 81 || !arrayEqual(again.Tags, []string{"a", "b"}) {
+		t.Fatalf("Got %+v", again)
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	Reset()
+	var conf serialConf
+	if err := ParseStruct([]string{"-n", "me", "--wait", "2s", "--upper", "x"}, &conf); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{
+  "debug": false,
+  "label": {},
+  "name": "me",
+  "port": 80,
+  "server": {
+    "host": ""
+  },
+  "tag": [],
+  "upper": "X",
+  "verbose": 0,
+  "wait": [
+    "2s"
+  ]
+}`
+	if string(doc) != expect {
+		t.Fatalf("Expect %s, got %s", expect, doc)
+	}
+}
+
+func TestToArgsEscape(t *testing.T) {
+	setup := func() (*string, *string, *[]string) {
+		Reset()
+		SetResponseFiles(ResponseShell, 1)
+		body := String("", "body", "", "", "", FileValue(TrimNone, 0))
+		dash := String("", "dash", "", "", "", FileValue(TrimNone, 0))
+		files := BareArray("file", "")
+		return body, dash, files
+	}
+	body, dash, files := setup()
+	if err := Parse([]string{"--body", "@@x", "--dash=@-", "--", "-x", "@y", "z"}); err != nil {
+		t.Fatal(err)
+	}
+	if *body != "@x" || *dash != "-" || !arrayEqual(*files, []string{"-x", "@y", "z"}) {
+		t.Fatalf("Got %s, %s, %q", *body, *dash, *files)
+	}
+	rebuilt, err := ToArgs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"--body=@@x", "--dash=@-", "--", "-x", "@y", "z"}
+	if !arrayEqual(rebuilt, expect) {
+		t.Fatalf("Expect %q, got %q", expect, rebuilt)
+	}
+
+	body, dash, files = setup()
+	if err := Parse(rebuilt); err != nil {
+		t.Fatal(err)
+	}
+	if *body != "@x" || *dash != "-" || !arrayEqual(*files, []string{"-x", "@y", "z"}) {
+		t.Fatalf("Got %s, %s, %q", *body, *dash, *files)
+	}
+
+	Reset()
+	BareArray("file", "")
+	if err := Parse([]string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if rebuilt, _ := ToArgs(); !arrayEqual(rebuilt, []string{"a", "b"}) {
+		t.Fatalf("Expect no \"--\" without need, got %q", rebuilt)
+	}
+}
+
+func TestToEnvSeparator(t *testing.T) {
+	Reset()
+	SetEnv(true, "")
+	tags := Array("", "tags", "", "")
+	if err := Parse([]string{"--tags", "a,b", "--tags", `c\`}); err != nil {
+		t.Fatal(err)
+	}
+	env, err := ToEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !arrayEqual(env, []string{`TAGS=a\,b,c\\`}) {
+		t.Fatalf("Got %q", env)
+	}
+	os.Setenv("TAGS", env[0][len("TAGS="):])
+	defer os.Unsetenv("TAGS")
+	Reset()
+	SetEnv(true, "")
+	tags = Array("", "tags", "", "")
+	if err := Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if !arrayEqual(*tags, []string{"a,b", `c\`}) {
+		t.Fatalf("Got %q", *tags)
+	}
+
+	Reset()
+	SetEnv(true, "")
+	Array("", "ports", "", "", Separator(";"))
+	labels := Map("", "labels", "", "")
+	if err := Parse([]string{"--labels", "k=v"}); err != nil {
+		t.Fatal(err)
+	}
+	(*labels)["k"] = "x,y"
+	if _, err := ToEnv(); err == nil || err.Error() != "arg<--labels> item <k=x,y> contains separator <,>" {
+		t.Fatalf("Expect separator error, got %v", err)
+	}
+}
+
+func TestToArgsSeparator(t *testing.T) {
+	Reset()
+	tags := Array("", "tags", "", "", Separator(","))
+	labels := Map("", "labels", "", "")
+	files := BareArray("file", "", Separator(":"))
+	if err := Parse([]string{"--tags", "a", "--labels", "k=v", "x"}); err != nil {
+		t.Fatal(err)
+	}
+	*tags = []string{"a,b", "c"}
+	if _, err := ToArgs(); err == nil || err.Error() != "arg<--tags> item <a,b> contains separator <,>" {
+		t.Fatalf("Expect array separator error, got %v", err)
+	}
+	*tags = []string{"a"}
+	(*labels)["k"] = "v1,v2"
+	if _, err := ToArgs(); err == nil || err.Error() != "arg<--labels> item <k=v1,v2> contains separator <,>" {
+		t.Fatalf("Expect map separator error, got %v", err)
+	}
+	(*labels)["k"] = "v"
+	*files = []string{"a:b"}
+	if _, err := ToArgs(); err == nil || err.Error() != "arg<file> item <a:b> contains separator <:>" {
+		t.Fatalf("Expect positional separator error, got %v", err)
+	}
+}
+
+func TestSerializeOptionalValue(t *testing.T) {
+	Reset()
+	var conf struct {
+		Level *upperValue `golf:"long:level"`
+	}
+	if err := ParseStruct([]string{}, &conf); err != nil {
+		t.Fatal(err)
+	}
+	rebuilt, err := ToArgs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rebuilt) != 0 || string(doc) != "{\n  \"level\": null\n}" {
+		t.Fatalf("Got %q and %s", rebuilt, doc)
+	}
+	if conf.Level != nil {
+		t.Fatalf("Expect level to stay nil, got %v", *conf.Level)
+	}
+}
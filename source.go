@@ -0,0 +1,202 @@
+package golf
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+type SourceKind int
+
+const (
+	SourceDefault SourceKind = iota
+	SourceFile
+	SourceEnv
+	SourceArgs
+)
+
+func (k SourceKind) String() string {
+	switch k {
+	case SourceDefault:
+		return "default"
+	case SourceFile:
+		return "file"
+	case SourceEnv:
+		return "env"
+	case SourceArgs:
+		return "arg"
+	default:
+		return fmt.Sprintf("source(%d)", int(k))
+	}
+}
+
+// Source tells where the value of an option came from: Name is the config
+// file or environment variable, Line the line in the file and Index the
+// position in the arguments given to Parse. Arguments read from response
+// files keep the index of their "@file" and the file position in Name.
+// Profile names the config profile a file value was taken from.
+type Source struct {
+	Kind    SourceKind
+	Name    string
+	Line    int
+	Index   int
+	Profile string
+}
+
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceFile:
+		if s.Profile != "" {
+			return fmt.Sprintf("file %s:%d [profile %s]", s.Name, s.Line, s.Profile)
+		}
+		return fmt.Sprintf("file %s:%d", s.Name, s.Line)
+	case SourceEnv:
+		return "env " + s.Name
+	case SourceArgs:
+		if s.Name != "" {
+			return fmt.Sprintf("arg #%d (%s)", s.Index, s.Name)
+		}
+		return fmt.Sprintf("arg #%d", s.Index)
+	default:
+		return s.Kind.String()
+	}
+}
+
+func (s Source) sameLayer(other Source) bool {
+	if s.Kind != other.Kind {
+		return false
+	}
+	// arguments are one layer, every config file is a layer on its own
+	return s.Kind != SourceFile || s.Name == other.Name
+}
+
+var defaultPrecedence = []SourceKind{SourceFile, SourceEnv, SourceArgs}
+
+// SetPrecedence orders config files, environment and arguments from lowest
+// to highest, defaults always come first. Every kind must be given once.
+func SetPrecedence(kinds ...SourceKind) {
+	if len(kinds) != len(defaultPrecedence) {
+		panic(fmt.Errorf("golf precedence requires %v, got %v", defaultPrecedence, kinds))
+	}
+	seen := map[SourceKind]bool{}
+	for _, kind := range kinds {
+		if kind == SourceDefault || kind > SourceArgs || seen[kind] {
+			panic(fmt.Errorf("golf precedence requires %v, got %v", defaultPrecedence, kinds))
+		}
+		seen[kind] = true
+	}
+	g.precedence = kinds
+}
+
+func (g *golf) rank(kind SourceKind) int {
+	order := g.precedence
+	if order == nil {
+		order = defaultPrecedence
+	}
+	for i, k := range order {
+		if k == kind {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// SetEnv makes Parse read options from environment variables named after
+// their long names, e.g. "APP_" and "--listen-addr" give APP_LISTEN_ADDR.
+// ParseStruct fields use Naming.Env instead of the long name. Arrays without
+// separator take ',' separated items, "\," is a comma inside an item and
+// "\\" a backslash.
+func SetEnv(enable bool, prefix string) {
+	g.useEnv = enable
+	g.envPrefix = prefix
+}
+
+var envReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+func envName(long string) string {
+	return strings.ToUpper(envReplacer.Replace(long))
+}
+
+// SourceOf returns where the current value of an option came from.
+func SourceOf(name string) (Source, bool) {
+	opt := findOpt(name)
+	if opt == nil {
+		return Source{}, false
+	}
+	return opt.Source, true
+}
+
+// setFrom parses value unless a source of higher precedence has already set
+// the option. A value from another layer replaces the current one instead of
+// adding to it, the replaced source is kept in Overridden.
+func (o *golfOpt) setFrom(src Source, value string) error {
+	if o.IsSet && !o.Source.sameLayer(src) {
+		if g.rank(src.Kind) < g.rank(o.Source.Kind) {
+			o.override(src)
+			return nil
+		}
+		o.override(o.Source)
+		o.IsSet = false
+		if o.Type == optCount {
+			o.ResultSetter.Target().SetInt(0)
+		}
+	}
+	if err := o.Parse(value); err != nil {
+		return err
+	}
+	o.Source = src
+	return nil
+}
+
+func (o *golfOpt) override(src Source) {
+	if n := len(o.Overridden); n != 0 && o.Overridden[n-1].sameLayer(src) {
+		o.Overridden[n-1] = src
+		return
+	}
+	o.Overridden = append(o.Overridden, src)
+}
+
+// splitEnvItems splits value at the commas not escaped by a backslash.
+func splitEnvItems(value string) []string {
+	items := make([]string, 0)
+	item := strings.Builder{}
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '\\' && i+1 < len(value) && (value[i+1] == ',' || value[i+1] == '\\'):
+			i++
+			item.WriteByte(value[i])
+		case c == ',':
+			items = append(items, item.String())
+			item.Reset()
+		default:
+			item.WriteByte(c)
+		}
+	}
+	return append(items, item.String())
+}
+
+func (g *golf) applyEnv() error {
+	if !g.useEnv {
+		return nil
+	}
+	for _, opt := range g.all {
+		if opt.Bare || opt.Env == "" {
+			continue
+		}
+		name := g.envPrefix + opt.Env
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		items := []string{value}
+		if opt.Type == optArray && opt.Sep == "" {
+			items = splitEnvItems(value)
+		}
+		for _, item := range items {
+			if err := opt.setFrom(Source{Kind: SourceEnv, Name: name}, item); err != nil {
+				return fmt.Errorf("env %s: %v", name, opt.maskError(err, value))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package golf
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSourcePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	conf := writeFile(t, dir, "app.conf", "# app\nport = 8000\nhost = file.local\ntag = a\ntag = b\n")
+	os.Setenv("APP_PORT", "9000")
+	os.Setenv("APP_USER", "env-user")
+	defer os.Unsetenv("APP_PORT")
+	defer os.Unsetenv("APP_USER")
+
+	Reset()
+	SetEnv(true, "APP_")
+	port := Int("p", "port", "", "", 80)
+	host := String("", "host", "", "", "localhost")
+	user := String("", "user", "", "", "")
+	tags := Array("t", "tag", "", "")
+	name := String("", "name", "", "", "none")
+	if err := LoadConfig(conf); err != nil {
+		t.Fatal(err)
+	}
+	if err := Parse([]string{"-t", "c", "--port=7000"}); err != nil {
+		t.Fatal(err)
+	}
+	if *port != 7000 || *host != "file.local" || *user != "env-user" || !arrayEqual(*tags, []string{"c"}) || *name != "none" {
+		t.Fatalf("Got %d, %s, %s, %q, %s", *port, *host, *user, *tags, *name)
+	}
+	for opt, expect := range map[string]string{
+		"port": "arg #2",
+		"host": "file " + conf + ":3",
+		"user": "env APP_USER",
+		"tag":  "arg #0",
+		"name": "default",
+	} {
+		if src, ok := SourceOf(opt); !ok || src.String() != expect {
+			t.Fatalf("%s: expect %s, got %s", opt, expect, src)
+		}
+	}
+	overridden := findOpt("port").Overridden
+	if len(overridden) != 2 || overridden[0].Kind != SourceEnv || overridden[1].Kind != SourceFile {
+		t.Fatalf("Expect env and file overridden, got %v", overridden)
+	}
+
+	Reset()
+	SetEnv(true, "APP_")
+	SetPrecedence(SourceEnv, SourceArgs, SourceFile)
+	port = Int("p", "port", "", "", 80)
+	String("", "host", "", "", "")
+	Array("t", "tag", "", "")
+	if err := LoadConfig(conf); err != nil {
+		t.Fatal(err)
+	}
+	if err := Parse([]string{"--port", "7000"}); err != nil {
+		t.Fatal(err)
+	}
+	if src, _ := SourceOf("port"); *port != 8000 || src.Kind != SourceFile {
+		t.Fatalf("Expect port from file, got %d from %s", *port, src)
+	}
+}
+
+func TestSourceResponseFile(t *testing.T) {
+	dir := t.TempDir()
+	resp := writeFile(t, dir, "args.txt", "-v\n-v\n--name bob\n")
+
+	Reset()
+	SetResponseFiles(ResponseShell, 1)
+	SetEnv(true, "")
+	os.Setenv("VERBOSE", "5")
+	defer os.Unsetenv("VERBOSE")
+	verbose := Count("v", "verbose", "", "")
+	String("", "name", "", "", "")
+	BareString("cmd", "")
+	if err := Parse([]string{"run", "@" + resp}); err != nil {
+		t.Fatal(err)
+	}
+	if *verbose != 2 {
+		t.Fatalf("Expect count from args only, got %d", *verbose)
+	}
+	if src, _ := SourceOf("name"); !strings.HasSuffix(src.String(), "args.txt:3)") || src.Index != 1 {
+		t.Fatalf("Got %s", src)
+	}
+	if src, _ := SourceOf("cmd"); src.String() != "arg #0" {
+		t.Fatalf("Got %s", src)
+	}
+}
+
+func TestSetPrecedence(t *testing.T) {
+	Reset()
+	expectPanic(t, "golf precedence requires [file env arg], got [arg arg env]", func() {
+		SetPrecedence(SourceArgs, SourceArgs, SourceEnv)
+	})
+	expectPanic(t, "golf precedence requires [file env arg], got [env arg]", func() {
+		SetPrecedence(SourceEnv, SourceArgs)
+	})
+}
+
+func TestEnvArray(t *testing.T) {
+	for value, expect := range map[string][]string{
+		`a,b`:       {"a", "b"},
+		`a\,b,c`:    {"a,b", "c"},
+		`C:\dir\\,`: {`C:\dir\`, ""},
+		``:          {""},
+	} {
+		if items := splitEnvItems(value); !arrayEqual(items, expect) {
+			t.Fatalf("%s: expect %q, got %q", value, expect, items)
+		}
+	}
+
+	os.Setenv("TAGS", `x\,y,z`)
+	os.Setenv("PORTS", "80;443")
+	defer os.Unsetenv("TAGS")
+	defer os.Unsetenv("PORTS")
+	Reset()
+	SetEnv(true, "")
+	tags := Array("", "tags", "", "")
+	ports := IntArray("", "ports", "", "", Separator(";"))
+	if err := Parse([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if !arrayEqual(*tags, []string{"x,y", "z"}) || len(*ports) != 2 || (*ports)[1] != 443 {
+		t.Fatalf("Got %q, %v", *tags, *ports)
+	}
+}
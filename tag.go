@@ -0,0 +1,166 @@
+package golf
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tagPart is one "key", "key: value", "key: 'value'" or `key: "value"` of a
+// golf tag, Col is the 1-based column of its key.
+type tagPart struct {
+	Key string
+	Val string
+	Raw string
+	Col int
+}
+
+type tagError struct {
+	Col      int
+	Expected string
+	Got      string
+}
+
+func (e tagError) Error() string {
+	return fmt.Sprintf("invalid golf tag at column %d: expected %s, got %s", e.Col, e.Expected, e.Got)
+}
+
+type tagParser struct {
+	src []rune
+	pos int
+}
+
+// parseTag splits a golf tag into its parts, parts are separated by ';' and
+// quoted values may contain ';', ':' and the escapes \\, \' and \", any other
+// backslash is kept as it is, e.g. in "\d+" or "C:\temp".
+// Keys may repeat, it is up to the caller to merge or override them.
+func parseTag(tag string) ([]tagPart, error) {
+	p := tagParser{src: []rune(tag)}
+	parts := make([]tagPart, 0)
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return parts, nil
+		}
+		if p.peek() == ';' {
+			// empty part, e.g. a trailing or doubled separator
+			p.pos++
+			continue
+		}
+		part, err := p.part()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+		p.skipSpace()
+		if p.eof() {
+			return parts, nil
+		}
+		if p.peek() != ';' {
+			return nil, p.errorf("';'")
+		}
+		p.pos++
+	}
+}
+
+func (p *tagParser) part() (tagPart, error) {
+	start := p.pos
+	key := p.key()
+	if key == "" {
+		return tagPart{}, p.errorf("key")
+	}
+	part := tagPart{Key: key, Col: start + 1}
+	p.skipSpace()
+	if !p.eof() && p.peek() == ':' {
+		p.pos++
+		p.skipSpace()
+		val, err := p.value()
+		if err != nil {
+			return tagPart{}, err
+		}
+		part.Val = val
+	} else if !p.eof() && p.peek() != ';' {
+		return tagPart{}, p.errorf("':' or ';'")
+	}
+	part.Raw = strings.TrimSpace(string(p.src[start:p.pos]))
+	return part, nil
+}
+
+func (p *tagParser) key() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != '-' {
+			break
+		}
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+func (p *tagParser) value() (string, error) {
+	if p.eof() || p.peek() == ';' {
+		return "", nil
+	}
+	if quote := p.peek(); quote == '\'' || quote == '"' {
+		return p.quoted(quote)
+	}
+	start := p.pos
+	for !p.eof() && p.peek() != ';' {
+		p.pos++
+	}
+	return strings.TrimSpace(string(p.src[start:p.pos])), nil
+}
+
+func (p *tagParser) quoted(quote rune) (string, error) {
+	open := p.pos
+	p.pos++
+	builder := strings.Builder{}
+	for !p.eof() {
+		c := p.peek()
+		p.pos++
+		switch c {
+		case quote:
+			return builder.String(), nil
+		case '\\':
+			if p.eof() {
+				builder.WriteRune(c)
+				continue
+			}
+			escaped := p.peek()
+			switch escaped {
+			case '\\', '\'', '"':
+				builder.WriteRune(escaped)
+			default:
+				builder.WriteRune(c)
+				builder.WriteRune(escaped)
+			}
+			p.pos++
+		default:
+			builder.WriteRune(c)
+		}
+	}
+	return "", tagError{Col: open + 1, Expected: fmt.Sprintf("closing %c", quote), Got: "end of tag"}
+}
+
+func (p *tagParser) errorf(expected string) tagError {
+	got := "end of tag"
+	if !p.eof() {
+		got = fmt.Sprintf("'%c'", p.peek())
+	}
+	return tagError{Col: p.pos + 1, Expected: expected, Got: got}
+}
+
+func (p *tagParser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+func (p *tagParser) peek() rune {
+	return p.src[p.pos]
+}
+
+func (p *tagParser) eof() bool {
+	return p.pos >= len(p.src)
+}
@@ -0,0 +1,68 @@
+package golf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseTag(t *testing.T) {
+	tag := `short:a; long: aaa ;help:'it\'s "quoted"; with\tescapes';required;` +
+		`default:"a;b";alias:x;alias: 'y' ;;url:http://host:80/p`
+	parts, err := parseTag(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []tagPart{
+		{Key: "short", Val: "a", Col: 1},
+		{Key: "long", Val: "aaa", Col: 10},
+		{Key: "help", Val: "it's \"quoted\"; with\\tescapes", Col: 21},
+		{Key: "required", Val: "", Col: 58},
+		{Key: "default", Val: "a;b", Col: 67},
+		{Key: "alias", Val: "x", Col: 81},
+		{Key: "alias", Val: "y", Col: 89},
+		{Key: "url", Val: "http://host:80/p", Col: 102},
+	}
+	if len(parts) != len(expect) {
+		t.Fatalf("Expect %d parts, got %d: %+v", len(expect), len(parts), parts)
+	}
+	for i, part := range parts {
+		if part.Key != expect[i].Key || part.Val != expect[i].Val || part.Col != expect[i].Col {
+			t.Fatalf("Part %d: expect %+v, got %+v", i, expect[i], part)
+		}
+	}
+}
+
+func TestParseTagError(t *testing.T) {
+	for tag, expect := range map[string]string{
+		"short:a;:b":         "invalid golf tag at column 9: expected key, got ':'",
+		"short a":            "invalid golf tag at column 7: expected ':' or ';', got 'a'",
+		"help:'unterminated": "invalid golf tag at column 6: expected closing ', got end of tag",
+		`help:"a" b`:         "invalid golf tag at column 10: expected ';', got 'b'",
+		"long=x":             "invalid golf tag at column 5: expected ':' or ';', got '='",
+	} {
+		if _, err := parseTag(tag); err == nil || err.Error() != expect {
+			t.Fatalf("%s: expect <%s>, got <%v>", tag, expect, err)
+		}
+	}
+}
+
+func TestStructTagEscape(t *testing.T) {
+	Reset()
+	type Config struct {
+		Name string `golf:"long:name;help:'the user\\'s name';alias:n1;alias:'--n2'"`
+		Path string `golf:"long:path;h:'match \\d+ in C:\\temp'"`
+		Bad  int    `golf:"long:bad;help:'unterminated"`
+	}
+	var conf Config
+	expect := "golf parse tag of [Bad] failed: invalid golf tag at column 15: expected closing ', got end of tag"
+	if err := ParseStruct([]string{}, &conf); err == nil || err.Error() != expect {
+		t.Fatalf("Expect <%s>, got <%v>", expect, err)
+	}
+	opt := g.longs["name"]
+	if opt == nil || opt.Help != "the user's name" || fmt.Sprint(opt.ShortAliases, opt.LongAliases) != "[] [n1 n2]" {
+		t.Fatalf("Got %+v", opt)
+	}
+	if opt := g.longs["path"]; opt == nil || opt.Help != `match \d+ in C:\temp` {
+		t.Fatalf("Expect unknown escapes kept, got %+v", opt)
+	}
+}
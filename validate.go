@@ -0,0 +1,102 @@
+package golf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ValidationError lists every problem found in the registered options.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("golf validation failed:\n  - %s", strings.Join(e.Problems, "\n  - "))
+}
+
+// SetStrict makes ParseStruct go on after a broken field and Parse refuse to
+// run until Validate passes, so all problems are reported together.
+func SetStrict(strict bool) {
+	g.strict = strict
+}
+
+// fail records err in strict mode, otherwise hands it back to the caller.
+func (g *golf) fail(err error) error {
+	if !g.strict {
+		return err
+	}
+	g.problems = append(g.problems, err.Error())
+	return nil
+}
+
+func Validate() error {
+	problems := append(append([]string{}, g.problems...), g.validate()...)
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// ValidateStruct checks the fields of v as ParseStruct would register them,
+// against the options registered so far, without registering anything.
+func ValidateStruct(v interface{}) error {
+	vpt := reflect.ValueOf(v)
+	if vpt.Kind() != reflect.Ptr || vpt.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("golf validate struct expects a pointer to struct")
+	}
+	saved := g
+	defer func() {
+		g = saved
+	}()
+	g.shorts = make(map[string]*golfOpt, len(saved.shorts))
+	for k, opt := range saved.shorts {
+		g.shorts[k] = opt
+	}
+	g.longs = make(map[string]*golfOpt, len(saved.longs))
+	for k, opt := range saved.longs {
+		g.longs[k] = opt
+	}
+	g.all = append([]*golfOpt{}, saved.all...)
+	g.lazies = nil
+	g.strict = true
+	g.problems = nil
+	// defaults are written while registering, keep them off the caller's struct
+	scratch := reflect.New(vpt.Elem().Type())
+	if err := g.addStruct(scratch.Elem(), structScope{}); err != nil {
+		return err
+	}
+	return Validate()
+}
+
+func (g *golf) validate() []string {
+	// duplicate and malformed names are refused by register already
+	problems := make([]string, 0)
+	for _, opt := range g.all {
+		if opt.Bare {
+			if opt.Name == "" {
+				problems = append(problems, fmt.Sprintf("%s: positional without name", opt.describe()))
+			}
+			continue
+		}
+		if opt.Short == "" && opt.Long == "" && len(opt.ShortAliases) == 0 && len(opt.LongAliases) == 0 {
+			problems = append(problems, fmt.Sprintf("%s: neither short nor long name", opt.describe()))
+		}
+	}
+	return problems
+}
+
+func (o golfOpt) describe() string {
+	desc := o.Origin
+	if desc == "" {
+		if arg := o.debugArg(); arg != "" {
+			desc = "arg<" + arg + ">"
+		} else {
+			desc = fmt.Sprintf("option <%s>", o.Help)
+		}
+	}
+	if o.Site != "" {
+		desc += " at " + o.Site
+	}
+	return desc
+}
@@ -0,0 +1,56 @@
+package golf
+
+import (
+	"testing"
+)
+
+type strictConfig struct {
+	Port    int    `golf:"short:p;long:port;default:'abc'"`
+	Host    string `golf:"short:p;long:host;colour:red"`
+	Name    string `golf:"help:'no name at all'"`
+	hidden  string `golf:"long:hidden"`
+	Timeout int    `golf:"long:timeout;default:5"`
+}
+
+func TestValidateStruct(t *testing.T) {
+	Reset()
+	_ = String("t", "timeout", "", "", "")
+	expect := `golf validation failed:
+  - golf parse tag of [Port] failed: invalid <default> val: abc
+  - golf parse tag of [Host] failed: parse tag [colour:red] failed: invalid tag option <colour>
+  - field [hidden] is unexported
+  - golf parse tag of [Timeout] failed: duplicate name --timeout: arg<-t/--timeout> at validate_test.go:N and field [Timeout]
+  - field [Name]: neither short nor long name`
+	var conf strictConfig
+	if err := ValidateStruct(&conf); err == nil || stripLines(err.Error()) != expect {
+		t.Fatalf("Expect <%s>, got <%v>", expect, err)
+	}
+	if len(g.all) != 1 || conf.Timeout != 0 {
+		t.Fatalf("Expect ValidateStruct to leave options and struct untouched")
+	}
+}
+
+func TestStrict(t *testing.T) {
+	Reset()
+	var conf strictConfig
+	if err := ParseStruct([]string{}, &conf); err == nil || err.Error() != "golf parse tag of [Port] failed: invalid <default> val: abc" {
+		t.Fatalf("Expect first error only without strict mode, got <%v>", err)
+	}
+
+	Reset()
+	SetStrict(true)
+	_ = BareInt("count", "", Optional("x"))
+	_ = Int("", "", "", "Orphan", 0)
+	_ = Int("c", "", "", "", 0)
+	_ = Bool("c", "", "", "", false)
+	expect := `golf validation failed:
+  - golf positional <count>: invalid <default> val: x
+  - duplicate name -c: arg<-c> at validate_test.go:N and arg<-c> at validate_test.go:N
+  - option <Orphan> at validate_test.go:N: neither short nor long name`
+	if err := Parse([]string{}); err == nil || stripLines(err.Error()) != expect {
+		t.Fatalf("Expect <%s>, got <%v>", expect, err)
+	}
+	if err, ok := Validate().(*ValidationError); !ok || len(err.Problems) != 3 {
+		t.Fatalf("Expect ValidationError with 3 problems, got %v", err)
+	}
+}